@@ -50,13 +50,34 @@ func migrateDatabase(db *gorm.DB) error {
 		&models.User{},
 		&models.Category{},
 		&models.Product{},
+		&models.ProductOptionGroup{},
+		&models.ProductOption{},
+		&models.Checkout{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderItemOption{},
 		&models.Transaction{},
 		&models.StandSettings{},
 		&models.GlobalSettings{},
 		&models.Cart{},
 		&models.CartItem{},
+		&models.CartItemOption{},
+		&models.TopUpRequest{},
+		&models.Settlement{},
+		&models.SettlementLine{},
+		&models.PayoutBatch{},
+		&models.IdempotencyKey{},
+		&models.DocumentSequence{},
+		&models.BalanceAdjustment{},
+		&models.StudentDeparture{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.OrderStatusHistory{},
+		&models.PickupSlot{},
+		&models.FavoriteProduct{},
+		&models.UsualOrder{},
+		&models.UsualOrderItem{},
+		&models.Review{},
 	)
 
 	if err != nil {
@@ -67,13 +88,34 @@ func migrateDatabase(db *gorm.DB) error {
 	log.Println("  - users")
 	log.Println("  - categories")
 	log.Println("  - products")
+	log.Println("  - product_option_groups")
+	log.Println("  - product_options")
+	log.Println("  - checkouts")
 	log.Println("  - orders")
 	log.Println("  - order_items")
+	log.Println("  - order_item_options")
 	log.Println("  - transactions")
 	log.Println("  - stand_settings")
 	log.Println("  - global_settings")
 	log.Println("  - carts")
 	log.Println("  - cart_items")
+	log.Println("  - cart_item_options")
+	log.Println("  - top_up_requests")
+	log.Println("  - settlements")
+	log.Println("  - settlement_lines")
+	log.Println("  - payout_batches")
+	log.Println("  - idempotency_keys")
+	log.Println("  - document_sequences")
+	log.Println("  - balance_adjustments")
+	log.Println("  - student_departures")
+	log.Println("  - notifications")
+	log.Println("  - notification_preferences")
+	log.Println("  - order_status_histories")
+	log.Println("  - pickup_slots")
+	log.Println("  - favorite_products")
+	log.Println("  - usual_orders")
+	log.Println("  - usual_order_items")
+	log.Println("  - reviews")
 
 	// Insert default global settings if they don't exist
 	var settingsCount int64
@@ -85,6 +127,16 @@ func migrateDatabase(db *gorm.DB) error {
 			{Key: "discount_rate", Value: "0"},
 			{Key: "school_name", Value: "Swipeup School"},
 			{Key: "currency", Value: "IDR"},
+			{Key: "school_timezone", Value: "Asia/Jakarta"},
+			{Key: "commission_rate", Value: "0"},
+			{Key: "adjustment_approval_threshold", Value: "100000"},
+			{Key: "low_balance_threshold", Value: "10000"},
+			{Key: "large_purchase_threshold", Value: "50000"},
+			{Key: "notification_channels", Value: "inbox"},
+			{Key: "preorder_max_days_ahead", Value: "3"},
+			{Key: "preorder_cancel_cutoff_minutes", Value: "60"},
+			{Key: "qris_payment_timeout_minutes", Value: "30"},
+			{Key: "cart_hold_minutes", Value: "0"}, // Cart stock holds are off until set, e.g. 10
 		}
 		if err := db.Create(&defaultSettings).Error; err != nil {
 			log.Printf("Warning: Failed to insert default settings: %v", err)
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"swipeup-admin-v2/internal/app/database"
+	"swipeup-admin-v2/internal/app/reconcile"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	repair := flag.Bool("repair", false, "post correcting adjustment entries for drifting wallets")
+	reason := flag.String("reason", "", "audit reason recorded on repair adjustments (required with -repair)")
+	users := flag.String("users", "", "comma-separated user IDs to check (default: all users)")
+	flag.Parse()
+
+	// Load .env file
+	err := godotenv.Load()
+	if err != nil {
+		log.Printf("Warning: Error loading .env file: %v", err)
+	}
+
+	if *repair && *reason == "" {
+		log.Fatal("-reason is required when -repair is set")
+	}
+
+	var userIDs []uint
+	if *users != "" {
+		for _, raw := range strings.Split(*users, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+			if err != nil {
+				log.Fatalf("Invalid user ID %q: %v", raw, err)
+			}
+			userIDs = append(userIDs, uint(id))
+		}
+	}
+
+	// Initialize database connection
+	db, err := database.InitDB()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	report, err := reconcile.Run(db, reconcile.Options{
+		UserIDs: userIDs,
+		Repair:  *repair,
+		Reason:  *reason,
+	})
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	log.Printf("Checked %d wallets, %d with issues, total drift %.2f, %d orphaned transactions",
+		report.WalletsChecked, report.WalletsWithIssues, report.TotalDrift, len(report.Orphans))
+	for _, w := range report.Wallets {
+		log.Printf("  - user %d (%s): balance %.2f, ledger %.2f, drift %.2f, %d issues",
+			w.UserID, w.Name, w.Balance, w.LedgerBalance, w.Drift, len(w.Issues))
+	}
+	if *repair {
+		log.Printf("Posted %d repair adjustments", len(report.Repairs))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	if report.WalletsWithIssues > 0 || len(report.Orphans) > 0 {
+		os.Exit(1)
+	}
+}
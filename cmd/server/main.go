@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"log"
 	"swipeup-admin-v2/internal/app/database"
+	"swipeup-admin-v2/internal/app/jobs"
 	"swipeup-admin-v2/internal/routes"
 
 	"github.com/gin-contrib/cors"
@@ -22,6 +24,9 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	// Start background jobs (order expiry, notifications, sweepers)
+	go jobs.Default(db).Run(context.Background())
+
 	// Set Gin mode
 	gin.SetMode(gin.DebugMode)
 
@@ -32,7 +37,7 @@ func main() {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:3000"}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With", "Idempotency-Key", "Last-Event-ID"}
 	config.AllowCredentials = true
 	router.Use(cors.New(config))
 
@@ -0,0 +1,244 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/settings"
+	"swipeup-admin-v2/internal/app/wallet"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AdjustmentHandler handles admin balance adjustments
+type AdjustmentHandler struct {
+	db *gorm.DB
+}
+
+// NewAdjustmentHandler creates a new AdjustmentHandler instance
+func NewAdjustmentHandler(db *gorm.DB) *AdjustmentHandler {
+	return &AdjustmentHandler{db: db}
+}
+
+// defaultApprovalThreshold applies when adjustment_approval_threshold is not configured
+const defaultApprovalThreshold = 100000
+
+// CreateAdjustment adjusts a user's balance. Adjustments above the configured
+// threshold wait for approval by a second admin.
+func (h *AdjustmentHandler) CreateAdjustment(c *gin.Context) {
+	id := c.Param("id")
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Amount     float64 `json:"amount" binding:"required"`
+		ReasonCode string  `json:"reason_code" binding:"required,oneof=correction lost_card manual_refund cash_deposit promotion other"`
+		Comment    string  `json:"comment" binding:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	adjustment := models.BalanceAdjustment{
+		UserID:        user.ID,
+		Amount:        req.Amount,
+		ReasonCode:    req.ReasonCode,
+		Comment:       req.Comment,
+		Status:        "pending_approval",
+		RequestedByID: adminID.(uint),
+	}
+
+	threshold := settings.GetFloat(h.db, "adjustment_approval_threshold", defaultApprovalThreshold)
+	if math.Abs(req.Amount) > threshold {
+		if err := h.db.Create(&adjustment).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create adjustment"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message":    fmt.Sprintf("Adjustment above %.0f requires approval by another admin", threshold),
+			"adjustment": adjustment,
+		})
+		return
+	}
+
+	tx := h.db.Begin()
+	if err := tx.Create(&adjustment).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create adjustment"})
+		return
+	}
+	if status, err := h.apply(tx, &adjustment, adminID.(uint), ""); err != nil {
+		tx.Rollback()
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Balance adjusted successfully",
+		"adjustment": adjustment,
+	})
+}
+
+// GetAdjustments returns balance adjustments, optionally filtered by status or user
+func (h *AdjustmentHandler) GetAdjustments(c *gin.Context) {
+	query := h.db.Preload("User").Preload("RequestedBy").Preload("ReviewedBy").Order("created_at DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var adjustments []models.BalanceAdjustment
+	if err := query.Find(&adjustments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch adjustments"})
+		return
+	}
+	c.JSON(http.StatusOK, adjustments)
+}
+
+// ApproveAdjustment applies a pending adjustment. The approver must not be the requester.
+func (h *AdjustmentHandler) ApproveAdjustment(c *gin.Context) {
+	id := c.Param("id")
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := h.db.Begin()
+
+	var adjustment models.BalanceAdjustment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&adjustment, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Adjustment not found"})
+		return
+	}
+	if adjustment.Status != "pending_approval" {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Adjustment is not pending approval"})
+		return
+	}
+	if adjustment.RequestedByID == adminID.(uint) {
+		tx.Rollback()
+		c.JSON(http.StatusForbidden, gin.H{"error": "Adjustment must be approved by a different admin"})
+		return
+	}
+
+	if status, err := h.apply(tx, &adjustment, adminID.(uint), req.Note); err != nil {
+		tx.Rollback()
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Adjustment approved and applied",
+		"adjustment": adjustment,
+	})
+}
+
+// RejectAdjustment rejects a pending adjustment
+func (h *AdjustmentHandler) RejectAdjustment(c *gin.Context) {
+	id := c.Param("id")
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var adjustment models.BalanceAdjustment
+	if err := h.db.First(&adjustment, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Adjustment not found"})
+		return
+	}
+	if adjustment.Status != "pending_approval" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Adjustment is not pending approval"})
+		return
+	}
+
+	reviewerID := adminID.(uint)
+	now := time.Now()
+	adjustment.Status = "rejected"
+	adjustment.ReviewedByID = &reviewerID
+	adjustment.ReviewedAt = &now
+	adjustment.ReviewNote = req.Note
+
+	if err := h.db.Save(&adjustment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject adjustment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Adjustment rejected",
+		"adjustment": adjustment,
+	})
+}
+
+// apply posts the adjustment to the wallet and marks it applied.
+// Returns the HTTP status to use when it fails.
+func (h *AdjustmentHandler) apply(tx *gorm.DB, adjustment *models.BalanceAdjustment, reviewerID uint, note string) (int, error) {
+	transactionNumber, err := numbering.Next(tx, numbering.Adjustment, 0)
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to generate transaction number")
+	}
+
+	transaction, err := wallet.Adjust(tx, wallet.Entry{
+		UserID:            adjustment.UserID,
+		TransactionNumber: transactionNumber,
+		Amount:            adjustment.Amount,
+		Description:       fmt.Sprintf("Adjustment (%s): %s", adjustment.ReasonCode, adjustment.Comment),
+	})
+	if errors.Is(err, wallet.ErrInsufficientBalance) {
+		return http.StatusBadRequest, errors.New("Adjustment would make the balance negative")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to adjust balance")
+	}
+
+	now := time.Now()
+	adjustment.Status = "applied"
+	adjustment.ReviewedByID = &reviewerID
+	adjustment.ReviewedAt = &now
+	adjustment.ReviewNote = note
+	adjustment.TransactionID = &transaction.ID
+
+	if err := tx.Save(adjustment).Error; err != nil {
+		return http.StatusInternalServerError, errors.New("Failed to update adjustment")
+	}
+	return http.StatusOK, nil
+}
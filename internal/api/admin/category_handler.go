@@ -3,6 +3,7 @@ package admin
 import (
 	"net/http"
 	"swipeup-admin-v2/internal/app/models"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -155,6 +156,14 @@ func (h *CategoryHandler) UpdateGlobalSetting(c *gin.Context) {
 		return
 	}
 
+	// Reports and date filters depend on a loadable time zone
+	if key == "school_timezone" {
+		if _, err := time.LoadLocation(req.Value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time zone, use an IANA name like Asia/Jakarta"})
+			return
+		}
+	}
+
 	var setting models.GlobalSettings
 	if err := h.db.Where("`key` = ?", key).First(&setting).Error; err != nil {
 		// Create new setting if not found
@@ -0,0 +1,333 @@
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"swipeup-admin-v2/internal/app/auth"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/wallet"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DepartureHandler handles the end-of-year cash-out and graduation workflow
+type DepartureHandler struct {
+	db *gorm.DB
+}
+
+// NewDepartureHandler creates a new DepartureHandler instance
+func NewDepartureHandler(db *gorm.DB) *DepartureHandler {
+	return &DepartureHandler{db: db}
+}
+
+// FlagDepartures flags students as departing, by class or by user IDs
+func (h *DepartureHandler) FlagDepartures(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Class   string `json:"class"`
+		UserIDs []uint `json:"user_ids"`
+		Reason  string `json:"reason" binding:"required,oneof=graduated transferred left"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Class == "" && len(req.UserIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either class or user_ids is required"})
+		return
+	}
+
+	query := h.db.Where("role = ? AND is_active = ?", "student", true)
+	if req.Class != "" {
+		query = query.Where("class = ?", req.Class)
+	}
+	if len(req.UserIDs) > 0 {
+		query = query.Where("id IN ?", req.UserIDs)
+	}
+
+	var students []models.User
+	if err := query.Find(&students).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch students"})
+		return
+	}
+
+	// Skip students that already have an open departure
+	var alreadyFlagged []uint
+	h.db.Model(&models.StudentDeparture{}).Where("status = ?", "flagged").Pluck("user_id", &alreadyFlagged)
+	skip := make(map[uint]bool, len(alreadyFlagged))
+	for _, id := range alreadyFlagged {
+		skip[id] = true
+	}
+
+	departures := []models.StudentDeparture{}
+	for _, student := range students {
+		if skip[student.ID] {
+			continue
+		}
+		departures = append(departures, models.StudentDeparture{
+			UserID:        student.ID,
+			Class:         student.Class,
+			Reason:        req.Reason,
+			Status:        "flagged",
+			FlaggedByID:   adminID.(uint),
+			BalanceAtFlag: student.Balance,
+		})
+	}
+
+	if len(departures) > 0 {
+		if err := h.db.Create(&departures).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to flag students"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    fmt.Sprintf("%d students flagged as departing", len(departures)),
+		"skipped":    len(students) - len(departures),
+		"departures": departures,
+	})
+}
+
+// GetDepartures returns the refund list with each student's current balance.
+// Use format=csv to download it for the office.
+func (h *DepartureHandler) GetDepartures(c *gin.Context) {
+	query := h.db.Preload("User").Preload("Sibling").Order("class ASC, id ASC")
+	if status := c.DefaultQuery("status", "flagged"); status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if class := c.Query("class"); class != "" {
+		query = query.Where("class = ?", class)
+	}
+
+	var departures []models.StudentDeparture
+	if err := query.Find(&departures).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch departures"})
+		return
+	}
+
+	var totalBalance float64
+	for _, d := range departures {
+		totalBalance += d.User.Balance
+	}
+
+	if c.Query("format") == "csv" {
+		c.Header("Content-Disposition", "attachment; filename=refund_list.csv")
+		c.Header("Content-Type", "text/csv")
+		writer := csv.NewWriter(c.Writer)
+		writer.Write([]string{"Departure ID", "Student ID", "Name", "Class", "Reason", "Status", "Balance", "Method", "Settled Amount"})
+		for _, d := range departures {
+			writer.Write([]string{
+				fmt.Sprintf("%d", d.ID),
+				d.User.StudentId,
+				d.User.Name,
+				d.Class,
+				d.Reason,
+				d.Status,
+				fmt.Sprintf("%.0f", d.User.Balance),
+				d.Method,
+				fmt.Sprintf("%.0f", d.SettledAmount),
+			})
+		}
+		writer.Write([]string{"", "", "", "", "", "Total", fmt.Sprintf("%.0f", totalBalance)})
+		writer.Flush()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"departures":    departures,
+		"count":         len(departures),
+		"total_balance": totalBalance,
+	})
+}
+
+// CompleteDeparture zeroes the wallet through the ledger (cash refund or transfer
+// to a sibling) and deactivates the account and RFID card
+func (h *DepartureHandler) CompleteDeparture(c *gin.Context) {
+	id := c.Param("id")
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Method    string `json:"method" binding:"required,oneof=cash sibling_transfer none"`
+		SiblingID uint   `json:"sibling_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := h.db.Begin()
+
+	var departure models.StudentDeparture
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&departure, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Departure not found"})
+		return
+	}
+	if departure.Status != "flagged" {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Departure is already completed"})
+		return
+	}
+
+	var student models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&student, departure.UserID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+
+	balance := student.Balance
+	if balance > 0 && req.Method == "none" {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Student still has a balance; use 'cash' or 'sibling_transfer'"})
+		return
+	}
+
+	if balance > 0 {
+		var transaction *models.Transaction
+		var err error
+
+		switch req.Method {
+		case "cash":
+			transaction, err = h.cashOut(tx, student, balance)
+		case "sibling_transfer":
+			if req.SiblingID == 0 || req.SiblingID == student.ID {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "A valid sibling_id is required for sibling_transfer"})
+				return
+			}
+			var sibling models.User
+			if err := tx.Where("id = ? AND role = ? AND is_active = ?", req.SiblingID, "student", true).First(&sibling).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Sibling must be an active student"})
+				return
+			}
+			transaction, err = h.transferToSibling(tx, student, sibling, balance)
+			departure.SiblingID = &sibling.ID
+		}
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to zero wallet: " + err.Error()})
+			return
+		}
+		departure.TransactionID = &transaction.ID
+	}
+
+	// Deactivate the account and RFID card
+	if err := tx.Model(&models.User{}).Where("id = ?", student.ID).Updates(map[string]interface{}{
+		"is_active":  false,
+		"rf_id_card": "",
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate account"})
+		return
+	}
+
+	settledByID := adminID.(uint)
+	now := time.Now()
+	departure.Status = "completed"
+	departure.Method = req.Method
+	departure.SettledAmount = balance
+	departure.SettledByID = &settledByID
+	departure.CompletedAt = &now
+
+	if err := tx.Save(&departure).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update departure"})
+		return
+	}
+
+	tx.Commit()
+
+	// Log the student out everywhere
+	auth.RevokeUserTokens(student.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Wallet settled and account deactivated",
+		"departure": departure,
+	})
+}
+
+// CancelDeparture removes a student from the departure list before completion
+func (h *DepartureHandler) CancelDeparture(c *gin.Context) {
+	id := c.Param("id")
+
+	var departure models.StudentDeparture
+	if err := h.db.First(&departure, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Departure not found"})
+		return
+	}
+	if departure.Status != "flagged" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Completed departures cannot be cancelled"})
+		return
+	}
+
+	if err := h.db.Delete(&departure).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel departure"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Departure cancelled successfully"})
+}
+
+// cashOut debits the full balance as a cash refund handed out by the office
+func (h *DepartureHandler) cashOut(tx *gorm.DB, student models.User, amount float64) (*models.Transaction, error) {
+	transactionNumber, err := numbering.Next(tx, numbering.CashOut, 0)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.Debit(tx, wallet.Entry{
+		UserID:            student.ID,
+		TransactionNumber: transactionNumber,
+		Type:              "cash_out",
+		Amount:            amount,
+		Description:       "Cash refund on departure",
+	})
+}
+
+// transferToSibling moves the full balance to a sibling's wallet
+func (h *DepartureHandler) transferToSibling(tx *gorm.DB, student, sibling models.User, amount float64) (*models.Transaction, error) {
+	outNumber, err := numbering.Next(tx, numbering.Transfer, 0)
+	if err != nil {
+		return nil, err
+	}
+	out, err := wallet.Debit(tx, wallet.Entry{
+		UserID:            student.ID,
+		TransactionNumber: outNumber,
+		Type:              "transfer_out",
+		Amount:            amount,
+		Description:       fmt.Sprintf("Balance transferred to sibling %s", sibling.Name),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inNumber, err := numbering.Next(tx, numbering.Transfer, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := wallet.Credit(tx, wallet.Entry{
+		UserID:            sibling.ID,
+		TransactionNumber: inNumber,
+		Type:              "transfer_in",
+		Amount:            amount,
+		Description:       fmt.Sprintf("Balance transferred from sibling %s", student.Name),
+	}); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
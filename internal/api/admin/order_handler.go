@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"swipeup-admin-v2/internal/app/ordersearch"
+	"swipeup-admin-v2/internal/app/settings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// OrderHandler handles order search across all stands for admins
+type OrderHandler struct {
+	db *gorm.DB
+}
+
+// NewOrderHandler creates a new OrderHandler instance
+func NewOrderHandler(db *gorm.DB) *OrderHandler {
+	return &OrderHandler{db: db}
+}
+
+// GetOrders searches orders of every stand, newest first, one page at a time.
+// Takes the stand search filters plus stand_id.
+func (h *OrderHandler) GetOrders(c *gin.Context) {
+	filter, err := ordersearch.Parse(c.Request.URL.Query(), settings.GetLocation(h.db))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if value := c.Query("stand_id"); value != "" {
+		standID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stand_id"})
+			return
+		}
+		filter.StandID = uint(standID)
+	}
+
+	page, err := ordersearch.Search(h.db.Preload("User").Preload("Stand").Preload("OrderItems.Product").Preload("OrderItems.Options").Preload("OrderItems.SubstituteProduct"), filter)
+	if errors.Is(err, ordersearch.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orders": page.Orders,
+		"pagination": gin.H{
+			"limit":       page.Limit,
+			"has_more":    page.HasMore,
+			"next_cursor": page.NextCursor,
+		},
+	})
+}
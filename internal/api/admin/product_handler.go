@@ -3,6 +3,7 @@ package admin
 import (
 	"net/http"
 	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/reviews"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -121,7 +122,31 @@ func (h *ProductHandler) GetStandCanteens(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stand canteens"})
 		return
 	}
-	c.JSON(http.StatusOK, standCanteens)
+
+	standIDs := make([]uint, 0, len(standCanteens))
+	for _, stand := range standCanteens {
+		standIDs = append(standIDs, stand.StandID)
+	}
+	stats, err := reviews.StandStats(h.db, standIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ratings"})
+		return
+	}
+
+	type StandCanteenResponse struct {
+		models.StandSettings
+		RatingAverage float64 `json:"rating_average"`
+		RatingCount   int64   `json:"rating_count"`
+	}
+	response := make([]StandCanteenResponse, 0, len(standCanteens))
+	for _, stand := range standCanteens {
+		response = append(response, StandCanteenResponse{
+			StandSettings: stand,
+			RatingAverage: stats[stand.StandID].Average,
+			RatingCount:   stats[stand.StandID].Count,
+		})
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // GetStandCanteen returns a single stand canteen by ID
@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"swipeup-admin-v2/internal/app/reconcile"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReconcileHandler handles wallet integrity checks for admin
+type ReconcileHandler struct {
+	db *gorm.DB
+}
+
+// NewReconcileHandler creates a new ReconcileHandler instance
+func NewReconcileHandler(db *gorm.DB) *ReconcileHandler {
+	return &ReconcileHandler{db: db}
+}
+
+// GetReconciliation checks every wallet and reports drift, chain breaks and orphans
+func (h *ReconcileHandler) GetReconciliation(c *gin.Context) {
+	var opts reconcile.Options
+	if userID := parseUint(c.Query("user_id")); userID != 0 {
+		opts.UserIDs = []uint{userID}
+	}
+
+	report, err := reconcile.Run(h.db, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile wallets: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// RepairWallets posts correcting adjustment entries for drifting wallets
+func (h *ReconcileHandler) RepairWallets(c *gin.Context) {
+	adminName, _ := c.Get("username")
+
+	var req struct {
+		Reason  string `json:"reason" binding:"required"`
+		UserIDs []uint `json:"user_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := reconcile.Run(h.db, reconcile.Options{
+		UserIDs: req.UserIDs,
+		Repair:  true,
+		Reason:  req.Reason + " (by " + adminName.(string) + ")",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to repair wallets: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Helper functions
+func parseUint(s string) uint {
+	var result uint
+	fmt.Sscanf(s, "%d", &result)
+	return result
+}
@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReviewHandler handles review moderation for admins
+type ReviewHandler struct {
+	db *gorm.DB
+}
+
+// NewReviewHandler creates a new ReviewHandler instance
+func NewReviewHandler(db *gorm.DB) *ReviewHandler {
+	return &ReviewHandler{db: db}
+}
+
+// GetReviews returns reviews newest first. Filters: stand_id, product_id,
+// user_id, max_rating, hidden=true|false, q (comment or reply contains).
+func (h *ReviewHandler) GetReviews(c *gin.Context) {
+	query := h.db.Preload("User").Preload("Product").Order("created_at DESC")
+	if standID := c.Query("stand_id"); standID != "" {
+		query = query.Where("stand_id = ?", standID)
+	}
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if maxRating := c.Query("max_rating"); maxRating != "" {
+		query = query.Where("rating <= ?", maxRating)
+	}
+	if hidden := c.Query("hidden"); hidden != "" {
+		query = query.Where("is_hidden = ?", hidden == "true")
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("comment LIKE ? OR reply LIKE ?", like, like)
+	}
+
+	var reviews []models.Review
+	if err := query.Limit(200).Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
+}
+
+// HideReview hides an abusive review from listings and rating averages
+func (h *ReviewHandler) HideReview(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var review models.Review
+	if err := h.db.First(&review, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	now := time.Now()
+	adminUserID := adminID.(uint)
+	if err := h.db.Model(&review).Updates(map[string]interface{}{
+		"is_hidden":     true,
+		"hidden_reason": strings.TrimSpace(req.Reason),
+		"hidden_by_id":  adminUserID,
+		"hidden_at":     now,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hide review"})
+		return
+	}
+	review.IsHidden = true
+	review.HiddenReason = strings.TrimSpace(req.Reason)
+	review.HiddenByID = &adminUserID
+	review.HiddenAt = &now
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review hidden", "review": review})
+}
+
+// UnhideReview makes a hidden review visible again
+func (h *ReviewHandler) UnhideReview(c *gin.Context) {
+	var review models.Review
+	if err := h.db.First(&review, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	if err := h.db.Model(&review).Updates(map[string]interface{}{
+		"is_hidden":     false,
+		"hidden_reason": "",
+		"hidden_by_id":  nil,
+		"hidden_at":     nil,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unhide review"})
+		return
+	}
+	review.IsHidden = false
+	review.HiddenReason = ""
+	review.HiddenByID = nil
+	review.HiddenAt = nil
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review visible again", "review": review})
+}
+
+// RemoveReply deletes an abusive stand reply from a review
+func (h *ReviewHandler) RemoveReply(c *gin.Context) {
+	var review models.Review
+	if err := h.db.First(&review, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	if err := h.db.Model(&review).Updates(map[string]interface{}{"reply": "", "replied_at": nil}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reply"})
+		return
+	}
+	review.Reply = ""
+	review.RepliedAt = nil
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reply removed", "review": review})
+}
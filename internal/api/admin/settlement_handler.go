@@ -0,0 +1,245 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/settlement"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SettlementHandler handles stand settlements and payout batches for admin
+type SettlementHandler struct {
+	db *gorm.DB
+}
+
+// NewSettlementHandler creates a new SettlementHandler instance
+func NewSettlementHandler(db *gorm.DB) *SettlementHandler {
+	return &SettlementHandler{db: db}
+}
+
+// PreviewSettlement computes the open period for a stand without closing it
+func (h *SettlementHandler) PreviewSettlement(c *gin.Context) {
+	var standID uint
+	if _, err := fmt.Sscanf(c.Query("stand_id"), "%d", &standID); err != nil || standID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stand_id is required"})
+		return
+	}
+
+	periodEnd, err := settlement.ParsePeriodEnd(c.Query("period_end"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := settlement.Compute(h.db, standID, periodEnd)
+	if err != nil {
+		if errors.Is(err, settlement.ErrInvalidPeriod) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute settlement"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// CloseSettlement closes a stand's open period into an immutable settlement statement
+func (h *SettlementHandler) CloseSettlement(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		StandID   uint   `json:"stand_id" binding:"required"`
+		PeriodEnd string `json:"period_end"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	periodEnd, err := settlement.ParsePeriodEnd(req.PeriodEnd)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if periodEnd.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot close a period that has not ended yet"})
+		return
+	}
+
+	var standSettings models.StandSettings
+	if err := h.db.Where("stand_id = ?", req.StandID).First(&standSettings).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stand canteen not found"})
+		return
+	}
+
+	tx := h.db.Begin()
+	closed, err := settlement.Close(tx, req.StandID, periodEnd, adminID.(uint))
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, settlement.ErrNothingToSettle) || errors.Is(err, settlement.ErrInvalidPeriod) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close settlement"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, closed)
+}
+
+// GetSettlements returns closed settlements, optionally filtered by stand
+func (h *SettlementHandler) GetSettlements(c *gin.Context) {
+	query := h.db.Preload("Stand").Order("period_end DESC")
+	if standID := c.Query("stand_id"); standID != "" {
+		query = query.Where("stand_id = ?", standID)
+	}
+
+	var settlements []models.Settlement
+	if err := query.Find(&settlements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settlements"})
+		return
+	}
+	c.JSON(http.StatusOK, settlements)
+}
+
+// GetSettlement returns a single settlement statement with its lines
+func (h *SettlementHandler) GetSettlement(c *gin.Context) {
+	id := c.Param("id")
+	var statement models.Settlement
+	if err := h.db.Preload("Stand").Preload("ClosedBy").Preload("Lines").First(&statement, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Settlement not found"})
+		return
+	}
+	c.JSON(http.StatusOK, statement)
+}
+
+// CreatePayoutBatch groups unpaid settlements into a payout batch
+func (h *SettlementHandler) CreatePayoutBatch(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		SettlementIDs []uint `json:"settlement_ids" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := h.db.Begin()
+
+	var settlements []models.Settlement
+	if err := tx.Where("id IN ? AND payout_batch_id IS NULL", req.SettlementIDs).Find(&settlements).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settlements"})
+		return
+	}
+	if len(settlements) != len(req.SettlementIDs) {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Some settlements do not exist or are already in a payout batch"})
+		return
+	}
+
+	batchNumber, err := numbering.Next(tx, numbering.PayoutBatch, 0)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate batch number"})
+		return
+	}
+
+	batch := models.PayoutBatch{
+		BatchNumber: batchNumber,
+		Status:      "pending",
+		CreatedByID: adminID.(uint),
+	}
+	for _, s := range settlements {
+		batch.TotalAmount += s.PayoutAmount
+	}
+
+	if err := tx.Create(&batch).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payout batch"})
+		return
+	}
+
+	result := tx.Model(&models.Settlement{}).
+		Where("id IN ? AND payout_batch_id IS NULL", req.SettlementIDs).
+		Update("payout_batch_id", batch.ID)
+	if result.Error != nil || result.RowsAffected != int64(len(req.SettlementIDs)) {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Settlements were batched concurrently, please retry"})
+		return
+	}
+
+	tx.Commit()
+
+	batch.Settlements = settlements
+	c.JSON(http.StatusCreated, batch)
+}
+
+// GetPayoutBatches returns all payout batches
+func (h *SettlementHandler) GetPayoutBatches(c *gin.Context) {
+	var batches []models.PayoutBatch
+	if err := h.db.Preload("Settlements.Stand").Order("created_at DESC").Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payout batches"})
+		return
+	}
+	c.JSON(http.StatusOK, batches)
+}
+
+// MarkPayoutBatchPaid records that a payout batch has been transferred to the stands
+func (h *SettlementHandler) MarkPayoutBatchPaid(c *gin.Context) {
+	id := c.Param("id")
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Reference string `json:"reference" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var batch models.PayoutBatch
+	if err := h.db.First(&batch, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payout batch not found"})
+		return
+	}
+	if batch.Status == "paid" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Payout batch is already paid"})
+		return
+	}
+
+	paidByID := adminID.(uint)
+	now := time.Now()
+	batch.Status = "paid"
+	batch.PaidByID = &paidByID
+	batch.PaidAt = &now
+	batch.Reference = req.Reference
+
+	if err := h.db.Save(&batch).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update payout batch"})
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/wallet"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TopUpHandler handles the top-up request review queue for admin
+type TopUpHandler struct {
+	db *gorm.DB
+}
+
+// NewTopUpHandler creates a new TopUpHandler instance
+func NewTopUpHandler(db *gorm.DB) *TopUpHandler {
+	return &TopUpHandler{db: db}
+}
+
+// GetTopUpRequests returns top-up requests, pending ones by default
+func (h *TopUpHandler) GetTopUpRequests(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+
+	query := h.db.Preload("User").Order("created_at ASC")
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var requests []models.TopUpRequest
+	if err := query.Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch top-up requests"})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// GetTopUpRequest returns a single top-up request by ID
+func (h *TopUpHandler) GetTopUpRequest(c *gin.Context) {
+	id := c.Param("id")
+	var request models.TopUpRequest
+	if err := h.db.Preload("User").Preload("ReviewedBy").Preload("Transaction").First(&request, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Top-up request not found"})
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+// ApproveTopUpRequest approves a pending request and credits the student's wallet
+func (h *TopUpHandler) ApproveTopUpRequest(c *gin.Context) {
+	id := c.Param("id")
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := h.db.Begin()
+
+	var request models.TopUpRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&request, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Top-up request not found"})
+		return
+	}
+
+	if request.Status != "pending" {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Top-up request has already been reviewed"})
+		return
+	}
+
+	transactionNumber, err := numbering.Next(tx, numbering.TopUp, 0)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate transaction number"})
+		return
+	}
+
+	// Credit through the same wallet path as a direct admin top-up
+	transaction, err := wallet.Credit(tx, wallet.Entry{
+		UserID:            request.UserID,
+		TransactionNumber: transactionNumber,
+		Type:              "top_up",
+		Amount:            request.Amount,
+		Description:       fmt.Sprintf("Balance top-up (transfer request #%d)", request.ID),
+	})
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to credit balance"})
+		return
+	}
+
+	reviewerID := adminID.(uint)
+	now := time.Now()
+	request.Status = "approved"
+	request.ReviewedByID = &reviewerID
+	request.ReviewedAt = &now
+	request.ReviewNote = req.Note
+	request.TransactionID = &transaction.ID
+
+	if err := tx.Save(&request).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update top-up request"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Top-up request approved",
+		"request": request,
+		"balance": transaction.BalanceAfter,
+	})
+}
+
+// RejectTopUpRequest rejects a pending request with a reason
+func (h *TopUpHandler) RejectTopUpRequest(c *gin.Context) {
+	id := c.Param("id")
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request models.TopUpRequest
+	if err := h.db.First(&request, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Top-up request not found"})
+		return
+	}
+
+	if request.Status != "pending" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Top-up request has already been reviewed"})
+		return
+	}
+
+	reviewerID := adminID.(uint)
+	now := time.Now()
+	result := h.db.Model(&models.TopUpRequest{}).
+		Where("id = ? AND status = ?", request.ID, "pending").
+		Updates(map[string]interface{}{
+			"status":         "rejected",
+			"reviewed_by_id": reviewerID,
+			"reviewed_at":    now,
+			"review_note":    req.Note,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update top-up request"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Top-up request has already been reviewed"})
+		return
+	}
+
+	request.Status = "rejected"
+	request.ReviewedByID = &reviewerID
+	request.ReviewedAt = &now
+	request.ReviewNote = req.Note
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Top-up request rejected",
+		"request": request,
+	})
+}
@@ -3,7 +3,8 @@ package admin
 import (
 	"net/http"
 	"swipeup-admin-v2/internal/app/models"
-	"time"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/wallet"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -22,30 +23,30 @@ func NewUserHandler(db *gorm.DB) *UserHandler {
 
 // CreateUserRequest represents the request payload for creating a user
 type CreateUserRequest struct {
-	Name      string  `json:"name" binding:"required"`
-	Email     string  `json:"email" binding:"required"`
-	Phone     string  `json:"phone"`
-	Role      string  `json:"role" binding:"required,oneof=student admin stand_admin"`
-	Class     string  `json:"class"`
-	Balance   float64 `json:"balance"`
-	IsActive  bool    `json:"is_active"`
-	RFIDCard  string  `json:"rfid_card"`
-	StudentId string  `json:"student_id"`
-	Password  string  `json:"password" binding:"required"`
+	Name      string   `json:"name" binding:"required"`
+	Email     string   `json:"email" binding:"required"`
+	Phone     string   `json:"phone"`
+	Role      string   `json:"role" binding:"required,oneof=student admin stand_admin"`
+	Class     string   `json:"class"`
+	Balance   *float64 `json:"balance"` // Rejected unless 0, use the adjustments endpoint
+	IsActive  bool     `json:"is_active"`
+	RFIDCard  string   `json:"rfid_card"`
+	StudentId string   `json:"student_id"`
+	Password  string   `json:"password" binding:"required"`
 }
 
 // UpdateUserRequest represents the request payload for updating a user
 type UpdateUserRequest struct {
-	Name      string  `json:"name"`
-	Email     string  `json:"email"`
-	Phone     string  `json:"phone"`
-	Role      string  `json:"role" binding:"omitempty,oneof=student admin stand_admin"`
-	Class     string  `json:"class"`
-	Balance   float64 `json:"balance"`
-	IsActive  bool    `json:"is_active"`
-	RFIDCard  string  `json:"rfid_card"`
-	StudentId string  `json:"student_id"`
-	Password  string  `json:"password"` // Optional for update
+	Name      string   `json:"name"`
+	Email     string   `json:"email"`
+	Phone     string   `json:"phone"`
+	Role      string   `json:"role" binding:"omitempty,oneof=student admin stand_admin"`
+	Class     string   `json:"class"`
+	Balance   *float64 `json:"balance"` // Rejected, use the adjustments endpoint
+	IsActive  bool     `json:"is_active"`
+	RFIDCard  string   `json:"rfid_card"`
+	StudentId string   `json:"student_id"`
+	Password  string   `json:"password"` // Optional for update
 }
 
 // GetUsers returns all users
@@ -95,6 +96,12 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	// New users start at zero; an opening balance must go through the ledger
+	if req.Balance != nil && *req.Balance != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Balance cannot be set here. Use POST /admin/users/:id/adjustments"})
+		return
+	}
+
 	// Check if email already exists
 	var existingUser models.User
 	if err := h.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
@@ -116,7 +123,6 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		Phone:     req.Phone,
 		Role:      req.Role, // Role is now validated and required
 		Class:     req.Class,
-		Balance:   req.Balance,
 		IsActive:  true,
 		RFIDCard:  req.RFIDCard,
 		StudentId: req.StudentId,
@@ -146,6 +152,12 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Balance changes must go through the ledger
+	if req.Balance != nil && *req.Balance != existingUser.Balance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Balance cannot be changed here. Use POST /admin/users/:id/adjustments"})
+		return
+	}
+
 	// If password is provided, hash it; otherwise keep existing password
 	if req.Password != "" {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -162,7 +174,6 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	existingUser.Phone = req.Phone
 	existingUser.Role = req.Role
 	existingUser.Class = req.Class
-	existingUser.Balance = req.Balance
 	existingUser.IsActive = req.IsActive
 	existingUser.RFIDCard = req.RFIDCard
 	existingUser.StudentId = req.StudentId
@@ -203,31 +214,25 @@ func (h *UserHandler) TopUpBalance(c *gin.Context) {
 		return
 	}
 
-	// Create transaction record
-	transaction := models.Transaction{
-		TransactionNumber: "TOPUP-" + time.Now().Format("20060102150405"),
-		UserID:            user.ID,
-		Type:              "top_up",
-		Amount:            req.Amount,
-		BalanceBefore:     user.Balance,
-		BalanceAfter:      user.Balance + req.Amount,
-		Description:       "Balance top-up",
-	}
-
-	// Update user balance
-	user.Balance += req.Amount
-
-	// Start transaction
+	// Credit wallet and create transaction record
 	tx := h.db.Begin()
-	if err := tx.Save(&user).Error; err != nil {
+	transactionNumber, err := numbering.Next(tx, numbering.TopUp, 0)
+	if err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update balance"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate transaction number"})
 		return
 	}
 
-	if err := tx.Create(&transaction).Error; err != nil {
+	transaction, err := wallet.Credit(tx, wallet.Entry{
+		UserID:            user.ID,
+		TransactionNumber: transactionNumber,
+		Type:              "top_up",
+		Amount:            req.Amount,
+		Description:       "Balance top-up",
+	})
+	if err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to top up balance"})
 		return
 	}
 
@@ -235,6 +240,6 @@ func (h *UserHandler) TopUpBalance(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Balance topped up successfully",
-		"balance": user.Balance,
+		"balance": transaction.BalanceAfter,
 	})
 }
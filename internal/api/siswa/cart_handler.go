@@ -1,10 +1,20 @@
 package siswa
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
+	"swipeup-admin-v2/internal/app/events"
+	"swipeup-admin-v2/internal/app/inventory"
 	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/options"
+	"swipeup-admin-v2/internal/app/orderstatus"
+	"swipeup-admin-v2/internal/app/pickup"
+	"swipeup-admin-v2/internal/app/settings"
+	"swipeup-admin-v2/internal/app/wallet"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -30,7 +40,7 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 	}
 
 	var cart models.Cart
-	if err := h.db.Where("user_id = ?", userID).Preload("CartItems.Product").First(&cart).Error; err != nil {
+	if err := h.db.Where("user_id = ?", userID).Preload("CartItems.Product").Preload("CartItems.Options").First(&cart).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// Create empty cart if not exists
 			cart = models.Cart{
@@ -58,8 +68,10 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 	}
 
 	var req struct {
-		ProductID uint `json:"product_id" binding:"required"`
-		Quantity  int  `json:"quantity" binding:"required,min=1"`
+		ProductID uint   `json:"product_id" binding:"required"`
+		Quantity  int    `json:"quantity" binding:"required,min=1"`
+		OptionIDs []uint `json:"option_ids"`
+		Note      string `json:"note" binding:"max=255"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -67,28 +79,87 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 		return
 	}
 
+	line := cartLine{ProductID: req.ProductID, Quantity: req.Quantity, OptionIDs: req.OptionIDs, Note: req.Note}
+	if _, err := h.addToCart(userID.(uint), line); err != nil {
+		c.JSON(err.Status, gin.H{"error": err.Message})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item added to cart successfully"})
+}
+
+// cartLine is a product, quantity and choice to put into a cart
+type cartLine struct {
+	ProductID uint
+	Quantity  int
+	OptionIDs []uint
+	Note      string
+}
+
+// cartError is a failed cart change with the response to send
+type cartError struct {
+	Status  int
+	Reason  string // unavailable, options_changed, out_of_stock; empty for server errors
+	Message string
+}
+
+// Error implements the error interface
+func (e *cartError) Error() string {
+	return e.Message
+}
+
+// priceLine checks that a line can be ordered and returns it as an unsaved
+// cart item at the current price
+func (h *CartHandler) priceLine(line cartLine) (*models.CartItem, *cartError) {
 	// Get product details
 	var product models.Product
-	if err := h.db.First(&product, req.ProductID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
-		return
+	if err := h.db.First(&product, line.ProductID).Error; err != nil {
+		return nil, &cartError{Status: http.StatusNotFound, Reason: "unavailable", Message: "Product not found"}
 	}
 
 	// Check if product is active and has stock
 	if !product.IsActive {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Product is not available"})
-		return
+		return nil, &cartError{Status: http.StatusBadRequest, Reason: "unavailable", Message: "Product is not available"}
 	}
-	if product.Stock < req.Quantity {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient stock"})
-		return
+	if product.Stock < line.Quantity {
+		return nil, &cartError{Status: http.StatusBadRequest, Reason: "out_of_stock", Message: "Insufficient stock"}
 	}
 
-	// Calculate price (with discount)
-	price := product.Price
-	if product.Discount > 0 {
-		price = product.Price * (1 - product.Discount/100)
+	// Check the chosen options and calculate price (with discount and option deltas)
+	selections, delta, err := options.Resolve(h.db, product.ID, line.OptionIDs)
+	if err != nil {
+		var selectionErr *options.SelectionError
+		if errors.As(err, &selectionErr) {
+			return nil, &cartError{Status: http.StatusBadRequest, Reason: "options_changed", Message: selectionErr.Error()}
+		}
+		return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to check product options"}
+	}
+	price := options.UnitPrice(product, delta)
+
+	return &models.CartItem{
+		ProductID: product.ID,
+		Product:   product,
+		Quantity:  line.Quantity,
+		Price:     price,
+		Subtotal:  price * float64(line.Quantity),
+		StandID:   product.StandID,
+		Options:   options.CartItemOptions(selections),
+		OptionKey: options.Key(selections),
+		Note:      strings.TrimSpace(line.Note),
+	}, nil
+}
+
+// addToCart puts a line into the user's cart at the current price, merging it
+// with an identical line, and holds its stock.
+func (h *CartHandler) addToCart(userID uint, line cartLine) (*models.CartItem, *cartError) {
+	priced, cartErr := h.priceLine(line)
+	if cartErr != nil {
+		return nil, cartErr
 	}
+	product := priced.Product
+	price := priced.Price
+	optionKey := priced.OptionKey
+	note := priced.Note
 
 	// Find or create cart
 	var cart models.Cart
@@ -96,76 +167,48 @@ func (h *CartHandler) AddToCart(c *gin.Context) {
 		if err == gorm.ErrRecordNotFound {
 			// Create new cart
 			cart = models.Cart{
-				UserID:     userID.(uint),
+				UserID:     userID,
 				TotalItems: 0,
 				TotalPrice: 0,
 			}
 			if err := h.db.Create(&cart).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cart"})
-				return
+				return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to create cart"}
 			}
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find cart"})
-			return
+			return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to find cart"}
 		}
 	}
 
-	// Check if cart already has items from different stand (single stand policy)
-	if cart.ID != 0 {
-		var existingItems []models.CartItem
-		if err := h.db.Where("cart_id = ?", cart.ID).Find(&existingItems).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing cart items"})
-			return
+	// Check if product already in cart with the same options and note
+	var cartItem models.CartItem
+	if err := h.db.Where("cart_id = ? AND product_id = ? AND option_key = ? AND note = ?", cart.ID, line.ProductID, optionKey, note).First(&cartItem).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to check cart item"}
 		}
-
-		// If cart has items from different stand, reject
-		if len(existingItems) > 0 && existingItems[0].StandID != product.StandID {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Cannot add products from different stands. Please checkout or clear cart first.",
-				"current_stand": existingItems[0].StandID,
-				"requested_stand": product.StandID,
-			})
-			return
+		// Add new item to cart
+		cartItem = models.CartItem{
+			CartID:    cart.ID,
+			ProductID: line.ProductID,
+			StandID:   product.StandID,
+			Options:   priced.Options,
+			OptionKey: optionKey,
+			Note:      note,
 		}
 	}
 
-	// Check if product already in cart
-	var existingItem models.CartItem
-	if err := h.db.Where("cart_id = ? AND product_id = ?", cart.ID, req.ProductID).First(&existingItem).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Add new item to cart
-			cartItem := models.CartItem{
-				CartID:    cart.ID,
-				ProductID: req.ProductID,
-				Quantity:  req.Quantity,
-				Price:     price,
-				Subtotal:  price * float64(req.Quantity),
-				StandID:   product.StandID,
-			}
-
-			if err := h.db.Create(&cartItem).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to cart"})
-				return
-			}
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check cart item"})
-			return
-		}
-	} else {
-		// Update existing item quantity
-		existingItem.Quantity += req.Quantity
-		existingItem.Subtotal = price * float64(existingItem.Quantity)
+	cartItem.Quantity += line.Quantity
+	cartItem.Price = price
+	cartItem.Subtotal = price * float64(cartItem.Quantity)
 
-		if err := h.db.Save(&existingItem).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
-			return
-		}
+	if err := h.saveWithHold(&cartItem); err != nil {
+		return nil, err
 	}
 
 	// Update cart totals
 	h.updateCartTotals(cart.ID)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Item added to cart successfully"})
+	cartItem.Product = product
+	return &cartItem, nil
 }
 
 // UpdateCartItem updates the quantity of a cart item
@@ -211,8 +254,8 @@ func (h *CartHandler) UpdateCartItem(c *gin.Context) {
 	cartItem.Quantity = req.Quantity
 	cartItem.Subtotal = cartItem.Price * float64(req.Quantity)
 
-	if err := h.db.Save(&cartItem).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
+	if err := h.saveWithHold(&cartItem); err != nil {
+		c.JSON(err.Status, gin.H{"error": err.Message})
 		return
 	}
 
@@ -283,6 +326,44 @@ func (h *CartHandler) ClearCart(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Cart cleared successfully"})
 }
 
+// checkoutRequest is the body of Checkout and CheckoutUsualOrder
+type checkoutRequest struct {
+	PaymentMethod string  `json:"payment_method" binding:"required"`
+	CashAmount    float64 `json:"cash_amount,omitempty"`    // Required for cash payment
+	PickupSlotID  uint    `json:"pickup_slot_id,omitempty"` // Pre-order for a pickup slot
+	PickupDate    string  `json:"pickup_date,omitempty"`    // YYYY-MM-DD, defaults to today
+}
+
+// validate checks the payment method and returns the pickup date of a
+// pre-order, read as a date in the school time zone loc
+func (r checkoutRequest) validate(loc *time.Location) (time.Time, *cartError) {
+	// Validate payment method
+	if r.PaymentMethod != "card" && r.PaymentMethod != "cash" && r.PaymentMethod != "qris" {
+		return time.Time{}, &cartError{Status: http.StatusBadRequest, Message: "Invalid payment method. Use 'card', 'cash', or 'qris'"}
+	}
+
+	// Validate cash amount for cash payment
+	if r.PaymentMethod == "cash" && r.CashAmount <= 0 {
+		return time.Time{}, &cartError{Status: http.StatusBadRequest, Message: "Cash amount is required for cash payment"}
+	}
+
+	// Pre-orders are paid when they are placed
+	pickupDate := time.Now()
+	if r.PickupSlotID != 0 {
+		if r.PaymentMethod == "cash" {
+			return time.Time{}, &cartError{Status: http.StatusBadRequest, Message: "Pre-orders must be paid by card or QRIS"}
+		}
+		if r.PickupDate != "" {
+			date, err := pickup.ParseDate(r.PickupDate, loc)
+			if err != nil {
+				return time.Time{}, &cartError{Status: http.StatusBadRequest, Message: "Invalid pickup_date format. Use YYYY-MM-DD"}
+			}
+			pickupDate = date
+		}
+	}
+	return pickupDate, nil
+}
+
 // Checkout creates orders from cart items
 func (h *CartHandler) Checkout(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -291,31 +372,20 @@ func (h *CartHandler) Checkout(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		PaymentMethod string  `json:"payment_method" binding:"required"`
-		CashAmount    float64 `json:"cash_amount,omitempty"` // Required for cash payment
-	}
-
+	var req checkoutRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// Validate payment method
-	if req.PaymentMethod != "card" && req.PaymentMethod != "cash" && req.PaymentMethod != "qris" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment method. Use 'card', 'cash', or 'qris'"})
-		return
-	}
-
-	// Validate cash amount for cash payment
-	if req.PaymentMethod == "cash" && req.CashAmount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cash amount is required for cash payment"})
+	pickupDate, cartErr := req.validate(settings.GetLocation(h.db))
+	if cartErr != nil {
+		c.JSON(cartErr.Status, gin.H{"error": cartErr.Message})
 		return
 	}
 
 	// Get cart with items
 	var cart models.Cart
-	if err := h.db.Where("user_id = ?", userID).Preload("CartItems.Product").First(&cart).Error; err != nil {
+	if err := h.db.Where("user_id = ?", userID).Preload("CartItems.Product").Preload("CartItems.Options").First(&cart).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found or empty"})
 		return
 	}
@@ -325,121 +395,424 @@ func (h *CartHandler) Checkout(c *gin.Context) {
 		return
 	}
 
-	// Get stand ID from first cart item (all items should be from same stand)
-	standID := cart.CartItems[0].StandID
-	var orderItems []models.OrderItem
-	var totalAmount float64
+	checkout, cartErr := h.placeOrders(userID.(uint), req, pickupDate, cart.ID, cart.CartItems)
+	if cartErr != nil {
+		c.JSON(cartErr.Status, gin.H{"error": cartErr.Message})
+		return
+	}
 
-	for _, cartItem := range cart.CartItems {
-		// Check stock again
-		if cartItem.Product.Stock < cartItem.Quantity {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient stock for product: " + cartItem.Product.Name})
-			return
-		}
+	// Clear cart after successful checkout
+	if err := h.db.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error; err != nil {
+		// Log error but don't fail the checkout
+		fmt.Printf("Warning: Failed to clear cart items: %v\n", err)
+	}
 
-		// Create order item
-		orderItem := models.OrderItem{
-			ProductID: cartItem.ProductID,
-			Quantity:  cartItem.Quantity,
-			Price:     cartItem.Price,
-			Subtotal:  cartItem.Subtotal,
-		}
+	// Reset cart totals
+	cart.TotalItems = 0
+	cart.TotalPrice = 0
+	if err := h.db.Save(&cart).Error; err != nil {
+		// Log error but don't fail the checkout
+		fmt.Printf("Warning: Failed to reset cart totals: %v\n", err)
+	}
 
-		orderItems = append(orderItems, orderItem)
-		totalAmount += cartItem.Subtotal
+	c.JSON(http.StatusCreated, h.checkoutResponse(checkout))
+}
 
-		// Reduce stock
-		cartItem.Product.Stock -= cartItem.Quantity
-		if err := h.db.Save(&cartItem.Product).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product stock"})
-			return
+// placeOrders splits the items into one order per stand, reserves their stock
+// and takes the payment in one transaction. Holds of cart cartID count as
+// available to the items.
+func (h *CartHandler) placeOrders(userID uint, req checkoutRequest, pickupDate time.Time, cartID uint, items []models.CartItem) (*models.Checkout, *cartError) {
+	// Split the items into one order per stand, in stand ID order
+	itemsByStand := make(map[uint][]models.CartItem)
+	var standIDs []uint
+	var totalAmount float64
+	for _, cartItem := range items {
+		if _, ok := itemsByStand[cartItem.StandID]; !ok {
+			standIDs = append(standIDs, cartItem.StandID)
 		}
+		itemsByStand[cartItem.StandID] = append(itemsByStand[cartItem.StandID], cartItem)
+		totalAmount += cartItem.Subtotal
 	}
+	sort.Slice(standIDs, func(i, j int) bool { return standIDs[i] < standIDs[j] })
 
-	// Generate order number
-	orderNumber := fmt.Sprintf("ORD-%d-%d-%d", userID, standID, time.Now().Unix())
+	// A pickup slot belongs to one stand
+	if req.PickupSlotID != 0 && len(standIDs) > 1 {
+		return nil, &cartError{Status: http.StatusBadRequest, Message: "Pre-orders can only contain items from one stand"}
+	}
 
 	// Determine initial status and validate payment based on method
-	initialStatus := "payment_pending"
+	initialStatus := orderstatus.PaymentPending
 	var cashAmount float64
-	var paymentProofURL string
 
 	switch req.PaymentMethod {
 	case "cash":
 		// Validate cash amount is sufficient
 		if req.CashAmount < totalAmount {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": fmt.Sprintf("Insufficient cash. Required: %.0f, Provided: %.0f", totalAmount, req.CashAmount),
-			})
-			return
+			return nil, &cartError{
+				Status:  http.StatusBadRequest,
+				Message: fmt.Sprintf("Insufficient cash. Required: %.0f, Provided: %.0f", totalAmount, req.CashAmount),
+			}
 		}
-		initialStatus = "request"
+		initialStatus = orderstatus.Request
 		cashAmount = req.CashAmount
 
 	case "qris":
-		// QRIS requires payment proof upload, so status remains payment_pending
-		initialStatus = "payment_pending"
+		// QRIS requires a payment proof per stand, so the orders stay payment_pending
+		initialStatus = orderstatus.PaymentPending
 
 	case "card":
-		// Assume card payment is instant, proceed to request
-		initialStatus = "request"
+		// Card payment is debited from the wallet below, proceed to request
+		initialStatus = orderstatus.Request
 	}
 
-	// Create order
-	order := models.Order{
-		OrderNumber:    orderNumber,
-		UserID:         userID.(uint),
-		TotalAmount:    totalAmount,
-		Status:         initialStatus,
+	// Start transaction
+	tx := h.db.Begin()
+
+	checkoutNumber, err := numbering.Next(tx, numbering.Checkout, 0)
+	if err != nil {
+		tx.Rollback()
+		return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to generate checkout number"}
+	}
+
+	checkout := models.Checkout{
+		CheckoutNumber: checkoutNumber,
+		UserID:         userID,
 		PaymentMethod:  req.PaymentMethod,
-		StandID:        standID,
-		OrderItems:     orderItems,
+		TotalAmount:    totalAmount,
 		CashAmount:     cashAmount,
-		PaymentProofURL: paymentProofURL,
+	}
+	if err := tx.Create(&checkout).Error; err != nil {
+		tx.Rollback()
+		return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to create checkout"}
 	}
 
-	if err := h.db.Create(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
-		return
+	actor := orderstatus.Actor{UserID: userID, Role: orderstatus.RoleStudent}
+	orders := make([]models.Order, 0, len(standIDs))
+	for _, standID := range standIDs {
+		var orderItems []models.OrderItem
+		var standTotal float64
+		lines := make([]inventory.Line, 0, len(itemsByStand[standID]))
+		for _, cartItem := range itemsByStand[standID] {
+			orderItems = append(orderItems, models.OrderItem{
+				ProductID: cartItem.ProductID,
+				Quantity:  cartItem.Quantity,
+				Price:     cartItem.Price,
+				Subtotal:  cartItem.Subtotal,
+				Options:   options.FromCartItem(cartItem.Options),
+				Note:      cartItem.Note,
+			})
+			standTotal += cartItem.Subtotal
+			lines = append(lines, inventory.Line{ProductID: cartItem.ProductID, Quantity: cartItem.Quantity})
+		}
+
+		// Reserve stock for the stand's items atomically; the cart's own holds count as available
+		if _, err := inventory.ReserveForCart(tx, lines, standID, cartID); err != nil {
+			tx.Rollback()
+			var stockErr *inventory.StockError
+			if errors.As(err, &stockErr) {
+				return nil, &cartError{Status: http.StatusBadRequest, Message: stockErr.Message()}
+			}
+			return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to update product stock"}
+		}
+
+		// Reserve the pickup slot for pre-orders
+		var scheduledFor *time.Time
+		if req.PickupSlotID != 0 {
+			slotTime, err := pickup.Reserve(tx, standID, req.PickupSlotID, pickupDate)
+			if err != nil {
+				tx.Rollback()
+				switch {
+				case errors.Is(err, pickup.ErrSlotNotFound):
+					return nil, &cartError{Status: http.StatusNotFound, Message: "Pickup slot not found"}
+				case errors.Is(err, pickup.ErrSlotFull):
+					return nil, &cartError{Status: http.StatusConflict, Message: "Pickup slot is full, please choose another slot"}
+				case errors.Is(err, pickup.ErrSlotClosed), errors.Is(err, pickup.ErrTooFarAhead):
+					return nil, &cartError{Status: http.StatusBadRequest, Message: err.Error()}
+				default:
+					return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to reserve pickup slot"}
+				}
+			}
+			scheduledFor = &slotTime
+		}
+
+		// Generate order number
+		orderNumber, err := numbering.Next(tx, numbering.Order, standID)
+		if err != nil {
+			tx.Rollback()
+			return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to generate order number"}
+		}
+
+		// Each stand collects its own share of the cash; a single-stand order keeps the amount handed over
+		orderCash := cashAmount
+		if req.PaymentMethod == "cash" && len(standIDs) > 1 {
+			orderCash = standTotal
+		}
+
+		order := models.Order{
+			OrderNumber:   orderNumber,
+			UserID:        userID,
+			TotalAmount:   standTotal,
+			Status:        initialStatus,
+			PaymentMethod: req.PaymentMethod,
+			StandID:       standID,
+			OrderItems:    orderItems,
+			CheckoutID:    &checkout.ID,
+			CashAmount:    orderCash,
+			ScheduledFor:  scheduledFor,
+		}
+		if req.PickupSlotID != 0 {
+			order.PickupSlotID = &req.PickupSlotID
+		}
+
+		if err := tx.Create(&order).Error; err != nil {
+			tx.Rollback()
+			return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to create order"}
+		}
+
+		if err := orderstatus.RecordCreated(tx, &order, actor); err != nil {
+			tx.Rollback()
+			return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to create order"}
+		}
+
+		orders = append(orders, order)
 	}
 
-	// Clear cart after successful checkout
-	if err := h.db.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error; err != nil {
-		// Log error but don't fail the checkout
-		fmt.Printf("Warning: Failed to clear cart items: %v\n", err)
+	// Pay with the student's wallet: one debit for the whole checkout. A
+	// single-stand debit is tied to its order; a split one to the checkout, and
+	// cancelling a sub-order refunds that order's total.
+	if req.PaymentMethod == "card" {
+		entry := wallet.Entry{
+			UserID:      userID,
+			Type:        "purchase",
+			Amount:      totalAmount,
+			Description: "Purchase: " + checkout.CheckoutNumber,
+			CheckoutID:  &checkout.ID,
+		}
+		numberStand := uint(0)
+		if len(orders) == 1 {
+			entry.Description = "Purchase: " + orders[0].OrderNumber
+			entry.OrderID = &orders[0].ID
+			numberStand = orders[0].StandID
+		}
+
+		entry.TransactionNumber, err = numbering.Next(tx, numbering.Purchase, numberStand)
+		if err != nil {
+			tx.Rollback()
+			return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to generate transaction number"}
+		}
+
+		if _, err := wallet.Debit(tx, entry); err != nil {
+			tx.Rollback()
+			if errors.Is(err, wallet.ErrInsufficientBalance) {
+				return nil, &cartError{Status: http.StatusBadRequest, Message: "Insufficient balance"}
+			}
+			return nil, &cartError{Status: http.StatusInternalServerError, Message: "Failed to update user balance"}
+		}
 	}
 
-	// Reset cart totals
-	cart.TotalItems = 0
-	cart.TotalPrice = 0
-	if err := h.db.Save(&cart).Error; err != nil {
-		// Log error but don't fail the checkout
-		fmt.Printf("Warning: Failed to reset cart totals: %v\n", err)
+	tx.Commit()
+
+	for _, order := range orders {
+		events.PublishOrder(events.OrderCreated, order)
 	}
+	checkout.Orders = orders
+	return &checkout, nil
+}
 
-	// Prepare response based on payment method
+// checkoutResponse describes a placed checkout, with each stand's QRIS code
+// for QRIS payments
+func (h *CartHandler) checkoutResponse(checkout *models.Checkout) gin.H {
+	orders := checkout.Orders
 	response := gin.H{
-		"message": "Checkout successful",
-		"order":   order, // Single order instead of array
-	}
-
-	// For QRIS payment, include QRIS code for the stand
-	if req.PaymentMethod == "qris" {
-		var settings models.StandSettings
-		if err := h.db.Where("stand_id = ?", standID).First(&settings).Error; err != nil {
-			// Log error but continue
-			fmt.Printf("Warning: Failed to get QRIS for stand %d: %v\n", standID, err)
-		} else if settings.QRIS != "" {
-			response["qris_code"] = gin.H{
-				"stand_id":   standID,
-				"qris_code": settings.QRIS,
-				"store_name": settings.StoreName,
+		"message":  "Checkout successful",
+		"checkout": checkout,
+		"orders":   orders,
+	}
+	if len(orders) == 1 {
+		// Single-stand checkouts keep the original response shape
+		response["order"] = orders[0]
+	}
+
+	// For QRIS payment, include each stand's QRIS code; every order is paid separately
+	if checkout.PaymentMethod == "qris" {
+		var qrisCodes []gin.H
+		for _, order := range orders {
+			var settings models.StandSettings
+			if err := h.db.Where("stand_id = ?", order.StandID).First(&settings).Error; err != nil {
+				// Log error but continue
+				fmt.Printf("Warning: Failed to get QRIS for stand %d: %v\n", order.StandID, err)
+				continue
+			}
+			if settings.QRIS == "" {
+				continue
+			}
+			qrisCodes = append(qrisCodes, gin.H{
+				"order_id":     order.ID,
+				"stand_id":     order.StandID,
+				"qris_code":    settings.QRIS,
+				"store_name":   settings.StoreName,
+				"total_amount": order.TotalAmount,
+			})
+		}
+		if len(qrisCodes) > 0 {
+			response["qris_codes"] = qrisCodes
+			if len(orders) == 1 {
+				response["qris_code"] = qrisCodes[0]
 			}
 			response["message"] = "Checkout successful. Please scan QRIS code to complete payment."
 		}
 	}
 
-	c.JSON(http.StatusCreated, response)
+	return response
+}
+
+// Reorder adds the items of a past order to the cart at today's prices. Items
+// already in the cart are kept. Items that are no longer available, whose
+// options changed or that are out of stock are left out and listed in skipped.
+func (h *CartHandler) Reorder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var order models.Order
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).
+		Preload("OrderItems.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("OrderItems.Options").
+		First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	// Previous prices by product and options, the same product can be ordered
+	// with different options at different prices
+	previousPrices := make(map[string]float64, len(order.OrderItems))
+	addedItems := make([]gin.H, 0, len(order.OrderItems))
+	skipped := []gin.H{}
+	for _, item := range order.OrderItems {
+		optionIDs := options.OrderOptionIDs(item.Options)
+		previousPrices[fmt.Sprintf("%d:%s", item.ProductID, options.KeyOf(optionIDs))] = item.Price
+
+		line := cartLine{ProductID: item.ProductID, Quantity: item.Quantity, OptionIDs: optionIDs, Note: item.Note}
+		added, err := h.addToCart(userID.(uint), line)
+		if err != nil {
+			if err.Reason == "" {
+				c.JSON(err.Status, gin.H{"error": err.Message})
+				return
+			}
+			skipped = append(skipped, skippedLine(line, item.Product.Name, err))
+			continue
+		}
+		addedItems = append(addedItems, gin.H{
+			"product_id":     added.ProductID,
+			"name":           added.Product.Name,
+			"quantity":       line.Quantity,
+			"price":          added.Price,
+			"previous_price": previousPrices[fmt.Sprintf("%d:%s", added.ProductID, added.OptionKey)],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Items of order " + order.OrderNumber + " added to your cart",
+		"added":   addedItems,
+		"skipped": skipped,
+		"cart":    h.loadCart(userID.(uint)),
+	})
+}
+
+// CheckoutUsualOrder checks out a usual order at today's prices with the same
+// body as Checkout. The student's cart is left as it is. If any item can't be
+// ordered nothing is placed and 409 lists the skipped items.
+func (h *CartHandler) CheckoutUsualOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req checkoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pickupDate, cartErr := req.validate(settings.GetLocation(h.db))
+	if cartErr != nil {
+		c.JSON(cartErr.Status, gin.H{"error": cartErr.Message})
+		return
+	}
+
+	var usual models.UsualOrder
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).
+		Preload("Items.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		First(&usual).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usual order not found"})
+		return
+	}
+
+	items := make([]models.CartItem, 0, len(usual.Items))
+	skipped := []gin.H{}
+	for _, item := range usual.Items {
+		line := cartLine{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			OptionIDs: options.ParseKey(item.OptionIDs),
+			Note:      item.Note,
+		}
+		priced, err := h.priceLine(line)
+		if err != nil {
+			if err.Reason == "" {
+				c.JSON(err.Status, gin.H{"error": err.Message})
+				return
+			}
+			skipped = append(skipped, skippedLine(line, item.Product.Name, err))
+			continue
+		}
+		items = append(items, *priced)
+	}
+	if len(skipped) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Some items of the usual order are unavailable. Update the usual order or order from the cart.",
+			"skipped": skipped,
+		})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Usual order is empty"})
+		return
+	}
+
+	// The student's own cart holds count as available, as they would at a cart checkout
+	var cart models.Cart
+	h.db.Where("user_id = ?", userID).First(&cart)
+
+	checkout, cartErr := h.placeOrders(userID.(uint), req, pickupDate, cart.ID, items)
+	if cartErr != nil {
+		c.JSON(cartErr.Status, gin.H{"error": cartErr.Message})
+		return
+	}
+
+	c.JSON(http.StatusCreated, h.checkoutResponse(checkout))
+}
+
+// skippedLine describes a line that couldn't be ordered and why
+func skippedLine(line cartLine, name string, err *cartError) gin.H {
+	return gin.H{
+		"product_id": line.ProductID,
+		"name":       name,
+		"quantity":   line.Quantity,
+		"reason":     err.Reason,
+		"message":    err.Message,
+	}
+}
+
+// loadCart returns the user's cart with its items, or an empty cart
+func (h *CartHandler) loadCart(userID uint) models.Cart {
+	var cart models.Cart
+	if err := h.db.Where("user_id = ?", userID).Preload("CartItems.Product").Preload("CartItems.Options").First(&cart).Error; err != nil {
+		return models.Cart{UserID: userID, CartItems: []models.CartItem{}}
+	}
+	return cart
 }
 
 // GetQRISCode returns QRIS code for a specific stand
@@ -564,14 +937,27 @@ func (h *CartHandler) UploadPaymentProof(c *gin.Context) {
 		return
 	}
 
-	// Update order with payment proof URL and change status
-	order.PaymentProofURL = filepath
-	order.Status = "request" // Now ready for stand processing
+	// Update order with payment proof URL and move it to the stand's queue
+	tx := h.db.Begin()
+	if err := tx.Model(&order).Update("payment_proof_url", filepath).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		return
+	}
 
-	if err := h.db.Save(&order).Error; err != nil {
+	actor := orderstatus.Actor{UserID: userID.(uint), Role: orderstatus.RoleStudent}
+	if err := orderstatus.Transition(tx, &order, orderstatus.Request, actor, "Payment proof uploaded"); err != nil {
+		tx.Rollback()
+		if errors.Is(err, orderstatus.ErrInvalidTransition) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Order is not eligible for payment proof upload"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
 		return
 	}
+	tx.Commit()
+
+	events.PublishOrder(events.PaymentProofUploaded, order)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Payment proof uploaded successfully",
@@ -579,6 +965,48 @@ func (h *CartHandler) UploadPaymentProof(c *gin.Context) {
 	})
 }
 
+// saveWithHold saves a cart item and, when cart holds are enabled, holds its
+// quantity in stock for the configured TTL.
+func (h *CartHandler) saveWithHold(cartItem *models.CartItem) *cartError {
+	tx := h.db.Begin()
+
+	// Lines of the same product with other options share the cart's hold
+	var otherLines int64
+	if err := tx.Model(&models.CartItem{}).
+		Where("cart_id = ? AND product_id = ? AND id <> ?", cartItem.CartID, cartItem.ProductID, cartItem.ID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&otherLines).Error; err != nil {
+		tx.Rollback()
+		return &cartError{Status: http.StatusInternalServerError, Message: "Failed to hold stock"}
+	}
+
+	expiresAt, err := inventory.Hold(tx, cartItem.CartID, cartItem.ProductID, cartItem.Quantity+int(otherLines))
+	if err != nil {
+		tx.Rollback()
+		var stockErr *inventory.StockError
+		if errors.As(err, &stockErr) {
+			if errors.Is(err, inventory.ErrInsufficientStock) {
+				return &cartError{Status: http.StatusBadRequest, Reason: "out_of_stock", Message: "Insufficient stock"}
+			}
+			return &cartError{Status: http.StatusBadRequest, Reason: "unavailable", Message: stockErr.Message()}
+		}
+		return &cartError{Status: http.StatusInternalServerError, Message: "Failed to hold stock"}
+	}
+
+	cartItem.HoldExpiresAt = expiresAt
+	cartItem.HeldQuantity = 0
+	if expiresAt != nil {
+		cartItem.HeldQuantity = cartItem.Quantity
+	}
+
+	if err := tx.Omit("Product", "Cart").Save(cartItem).Error; err != nil {
+		tx.Rollback()
+		return &cartError{Status: http.StatusInternalServerError, Message: "Failed to update cart item"}
+	}
+
+	tx.Commit()
+	return nil
+}
+
 // updateCartTotals recalculates and updates cart totals
 func (h *CartHandler) updateCartTotals(cartID uint) error {
 	var cart models.Cart
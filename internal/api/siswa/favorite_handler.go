@@ -0,0 +1,121 @@
+package siswa
+
+import (
+	"net/http"
+
+	"swipeup-admin-v2/internal/app/inventory"
+	"swipeup-admin-v2/internal/app/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FavoriteHandler handles favourite products for students
+type FavoriteHandler struct {
+	db *gorm.DB
+}
+
+// NewFavoriteHandler creates a new FavoriteHandler instance
+func NewFavoriteHandler(db *gorm.DB) *FavoriteHandler {
+	return &FavoriteHandler{db: db}
+}
+
+// GetFavorites returns the student's favourite products with their current availability
+func (h *FavoriteHandler) GetFavorites(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var favorites []models.FavoriteProduct
+	if err := h.db.Where("user_id = ?", userID).
+		Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("created_at DESC").
+		Find(&favorites).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch favorites"})
+		return
+	}
+
+	products := make([]models.Product, 0, len(favorites))
+	for _, favorite := range favorites {
+		products = append(products, favorite.Product)
+	}
+	available, err := inventory.AvailableStock(h.db, products, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock"})
+		return
+	}
+
+	type FavoriteResponse struct {
+		models.FavoriteProduct
+		IsAvailable    bool `json:"is_available"` // Active, not deleted and in stock
+		AvailableStock int  `json:"available_stock"`
+	}
+
+	response := make([]FavoriteResponse, 0, len(favorites))
+	for _, favorite := range favorites {
+		product := favorite.Product
+		response = append(response, FavoriteResponse{
+			FavoriteProduct: favorite,
+			IsAvailable:     product.IsActive && !product.DeletedAt.Valid && available[product.ID] > 0,
+			AvailableStock:  available[product.ID],
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AddFavorite pins a product to the student's favourites
+func (h *FavoriteHandler) AddFavorite(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		ProductID uint `json:"product_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.Product
+	if err := h.db.First(&product, req.ProductID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	favorite := models.FavoriteProduct{UserID: userID.(uint), ProductID: product.ID}
+	if err := h.db.Where(favorite).FirstOrCreate(&favorite).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add favorite"})
+		return
+	}
+	favorite.Product = product
+
+	c.JSON(http.StatusCreated, favorite)
+}
+
+// RemoveFavorite unpins a product from the student's favourites
+func (h *FavoriteHandler) RemoveFavorite(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// Deleted for good so the product can be pinned again
+	result := h.db.Unscoped().Where("user_id = ? AND product_id = ?", userID, c.Param("product_id")).Delete(&models.FavoriteProduct{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove favorite"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Favorite not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Favorite removed successfully"})
+}
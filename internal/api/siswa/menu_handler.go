@@ -2,7 +2,9 @@ package siswa
 
 import (
 	"net/http"
+	"swipeup-admin-v2/internal/app/inventory"
 	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/reviews"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -26,6 +28,12 @@ type ProductWithStand struct {
 
 // GetProducts returns all active products from all active stands
 func (h *MenuHandler) GetProducts(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	var products []models.Product
 	
 	// Get all active products from active stands
@@ -33,15 +41,49 @@ func (h *MenuHandler) GetProducts(c *gin.Context) {
 		Joins("JOIN stand_settings ON products.stand_id = stand_settings.stand_id AND stand_settings.is_active = ? AND stand_settings.deleted_at IS NULL", true).
 		Where("products.is_active = ?", true).
 		Preload("Category", "is_active = ?", true).
+		Preload("OptionGroups", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order, id") }).
+		Preload("OptionGroups.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Where("is_active = ?", true).Order("sort_order, id")
+		}).
 		Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
 		return
 	}
 
+	// Stock minus what other carts are holding
+	available, err := inventory.AvailableStock(h.db, products, userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock"})
+		return
+	}
+
+	// Ratings of the products and their stands
+	productIDs := make([]uint, 0, len(products))
+	standIDs := make([]uint, 0, len(products))
+	for _, p := range products {
+		productIDs = append(productIDs, p.ID)
+		standIDs = append(standIDs, p.StandID)
+	}
+	productStats, err := reviews.ProductStats(h.db, productIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ratings"})
+		return
+	}
+	standStats, err := reviews.StandStats(h.db, standIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ratings"})
+		return
+	}
+
 	// Build response with stand information
 	type ProductResponse struct {
 		models.Product
-		StandName string `json:"stand_name"`
+		StandName          string  `json:"stand_name"`
+		AvailableStock     int     `json:"available_stock"`
+		RatingAverage      float64 `json:"rating_average"`
+		RatingCount        int64   `json:"rating_count"`
+		StandRatingAverage float64 `json:"stand_rating_average"`
+		StandRatingCount   int64   `json:"stand_rating_count"`
 	}
 
 	var response []ProductResponse
@@ -51,8 +93,13 @@ func (h *MenuHandler) GetProducts(c *gin.Context) {
 		h.db.Where("stand_id = ?", p.StandID).First(&standSettings)
 		
 		response = append(response, ProductResponse{
-			Product:   p,
-			StandName: standSettings.StoreName,
+			Product:            p,
+			StandName:          standSettings.StoreName,
+			AvailableStock:     available[p.ID],
+			RatingAverage:      productStats[p.ID].Average,
+			RatingCount:        productStats[p.ID].Count,
+			StandRatingAverage: standStats[p.StandID].Average,
+			StandRatingCount:   standStats[p.StandID].Count,
 		})
 	}
 
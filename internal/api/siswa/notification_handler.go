@@ -0,0 +1,180 @@
+package siswa
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/notify"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// NotificationHandler handles the notification inbox and settings for students
+type NotificationHandler struct {
+	db *gorm.DB
+}
+
+// NewNotificationHandler creates a new NotificationHandler instance
+func NewNotificationHandler(db *gorm.DB) *NotificationHandler {
+	return &NotificationHandler{db: db}
+}
+
+// GetNotifications returns the current user's inbox, newest first.
+// Supports unread=true and page/page_size.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := h.db.Model(&models.Notification{}).Where("user_id = ? AND in_inbox = ?", userID, true)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	var unread int64
+	if err := h.db.Model(&models.Notification{}).
+		Where("user_id = ? AND in_inbox = ? AND read_at IS NULL", userID, true).
+		Count(&unread).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unread_count":  unread,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// MarkAsRead marks a single notification as read
+func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var notification models.Notification
+	if err := h.db.Where("id = ? AND user_id = ? AND in_inbox = ?", id, userID, true).First(&notification).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := h.db.Model(&notification).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+			return
+		}
+		notification.ReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllAsRead marks every unread notification of the current user as read
+func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result := h.db.Model(&models.Notification{}).
+		Where("user_id = ? AND in_inbox = ? AND read_at IS NULL", userID, true).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read", "updated": result.RowsAffected})
+}
+
+// GetSettings returns the current user's notification settings with the effective thresholds
+func (h *NotificationHandler) GetSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	c.JSON(http.StatusOK, notify.Preference(h.db, userID.(uint)))
+}
+
+// UpdateSettingsRequest represents the request body for notification settings.
+// A null threshold resets it to the school default, 0 turns the notification off.
+type UpdateSettingsRequest struct {
+	LowBalanceThreshold    *float64 `json:"low_balance_threshold" binding:"omitempty,min=0"`
+	LargePurchaseThreshold *float64 `json:"large_purchase_threshold" binding:"omitempty,min=0"`
+	Channels               []string `json:"channels"`
+}
+
+// UpdateSettings saves the current user's notification settings
+func (h *NotificationHandler) UpdateSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, channel := range req.Channels {
+		if !notify.ValidChannel(channel) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel: " + channel})
+			return
+		}
+	}
+
+	pref := notify.Preference(h.db, userID.(uint))
+	pref.LowBalanceThreshold = req.LowBalanceThreshold
+	pref.LargePurchaseThreshold = req.LargePurchaseThreshold
+	pref.Channels = strings.Join(req.Channels, ",")
+
+	if err := h.db.Save(&pref).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
@@ -1,9 +1,22 @@
 package siswa
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"swipeup-admin-v2/internal/app/events"
+	"swipeup-admin-v2/internal/app/inventory"
 	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/options"
+	"swipeup-admin-v2/internal/app/orderstatus"
+	"swipeup-admin-v2/internal/app/pickup"
+	"swipeup-admin-v2/internal/app/receipt"
+	"swipeup-admin-v2/internal/app/reporting"
+	"swipeup-admin-v2/internal/app/settings"
+	"swipeup-admin-v2/internal/app/wallet"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -31,8 +44,10 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var req struct {
 		PaymentMethod string `json:"payment_method" binding:"required"`
 		Items         []struct {
-			ProductID uint `json:"product_id" binding:"required"`
-			Quantity  int  `json:"quantity" binding:"required"`
+			ProductID uint   `json:"product_id" binding:"required"`
+			Quantity  int    `json:"quantity" binding:"required"`
+			OptionIDs []uint `json:"option_ids"`
+			Note      string `json:"note" binding:"max=255"`
 		} `json:"items" binding:"required"`
 	}
 
@@ -47,33 +62,46 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	// Group items by stand
-	standItems := make(map[uint][]models.OrderItem)
-	totalByStand := make(map[uint]float64)
+	// Start transaction
+	tx := h.db.Begin()
 
+	// Reserve stock for all items atomically
+	lines := make([]inventory.Line, 0, len(req.Items))
 	for _, item := range req.Items {
-		// Get product details
-		var product models.Product
-		if err := h.db.First(&product, item.ProductID).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Product not found: " + fmt.Sprintf("%d", item.ProductID)})
+		lines = append(lines, inventory.Line{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	products, err := inventory.Reserve(tx, lines, 0)
+	if err != nil {
+		tx.Rollback()
+		var stockErr *inventory.StockError
+		if errors.As(err, &stockErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": stockErr.Message()})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product stock"})
+		return
+	}
 
-		// Check if product is active and has stock
-		if !product.IsActive {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Product is not available: " + product.Name})
-			return
-		}
-		if product.Stock < item.Quantity {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient stock for product: " + product.Name})
-			return
-		}
+	// Group items by stand
+	standItems := make(map[uint][]models.OrderItem)
+	totalByStand := make(map[uint]float64)
 
-		// Calculate price (with discount)
-		price := product.Price
-		if product.Discount > 0 {
-			price = product.Price * (1 - product.Discount/100)
+	for _, item := range req.Items {
+		product := products[item.ProductID]
+
+		// Calculate price (with discount and option deltas)
+		selections, delta, err := options.Resolve(tx, product.ID, item.OptionIDs)
+		if err != nil {
+			tx.Rollback()
+			var selectionErr *options.SelectionError
+			if errors.As(err, &selectionErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": product.Name + ": " + selectionErr.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check product options"})
+			return
 		}
+		price := options.UnitPrice(product, delta)
 
 		// Create order item
 		orderItem := models.OrderItem{
@@ -81,6 +109,8 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 			Quantity:  item.Quantity,
 			Price:     price,
 			Subtotal:  price * float64(item.Quantity),
+			Options:   options.OrderItemOptions(selections),
+			Note:      strings.TrimSpace(item.Note),
 		}
 
 		// Group by stand
@@ -88,11 +118,23 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		totalByStand[product.StandID] += orderItem.Subtotal
 	}
 
-	// Create orders for each stand
+	// Create orders for each stand, in stand ID order so numbers are assigned predictably
+	standIDs := make([]uint, 0, len(standItems))
+	for standID := range standItems {
+		standIDs = append(standIDs, standID)
+	}
+	sort.Slice(standIDs, func(i, j int) bool { return standIDs[i] < standIDs[j] })
+
 	var createdOrders []models.Order
-	for standID, items := range standItems {
+	for _, standID := range standIDs {
+		items := standItems[standID]
 		// Generate order number
-		orderNumber := fmt.Sprintf("ORD-%d-%d-%d", userID, standID, time.Now().Unix())
+		orderNumber, err := numbering.Next(tx, numbering.Order, standID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate order number"})
+			return
+		}
 
 		// Create order
 		order := models.Order{
@@ -105,14 +147,54 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 			OrderItems:    items,
 		}
 
-		if err := h.db.Create(&order).Error; err != nil {
+		if err := tx.Create(&order).Error; err != nil {
+			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
 			return
 		}
 
+		if err := orderstatus.RecordCreated(tx, &order, orderstatus.Actor{UserID: userID.(uint), Role: orderstatus.RoleStudent}); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+			return
+		}
+
+		// Card orders are paid from the wallet, one debit per order so cancelling refunds it
+		if req.PaymentMethod == "card" {
+			transactionNumber, err := numbering.Next(tx, numbering.Purchase, standID)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate transaction number"})
+				return
+			}
+
+			if _, err := wallet.Debit(tx, wallet.Entry{
+				UserID:            userID.(uint),
+				TransactionNumber: transactionNumber,
+				Type:              "purchase",
+				Amount:            order.TotalAmount,
+				Description:       "Purchase: " + order.OrderNumber,
+				OrderID:           &order.ID,
+			}); err != nil {
+				tx.Rollback()
+				if errors.Is(err, wallet.ErrInsufficientBalance) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user balance"})
+				return
+			}
+		}
+
 		createdOrders = append(createdOrders, order)
 	}
 
+	tx.Commit()
+
+	for _, order := range createdOrders {
+		events.PublishOrder(events.OrderCreated, order)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Orders created successfully",
 		"orders":  createdOrders,
@@ -128,14 +210,15 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 	}
 
 	var orders []models.Order
-	if err := h.db.Where("user_id = ?", userID).Preload("OrderItems.Product").Preload("Stand").Find(&orders).Error; err != nil {
+	if err := h.db.Where("user_id = ?", userID).Preload("OrderItems.Product").Preload("OrderItems.Options").Preload("OrderItems.SubstituteProduct").Preload("Stand").Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
 		return
 	}
 	c.JSON(http.StatusOK, orders)
 }
 
-// GetOrdersByMonth returns orders for the current student filtered by month
+// GetOrdersByMonth returns orders for the current student filtered by month.
+// The month runs from midnight to midnight in the school time zone.
 func (h *OrderHandler) GetOrdersByMonth(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -143,45 +226,77 @@ func (h *OrderHandler) GetOrdersByMonth(c *gin.Context) {
 		return
 	}
 
-	year := c.Query("year")
-	month := c.Query("month")
-
-	if year == "" || month == "" {
+	if c.Query("year") == "" || c.Query("month") == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Year and month parameters are required"})
 		return
 	}
-
-	// Build date range for the month
-	startDate := fmt.Sprintf("%s-%s-01", year, month)
-	endDate := fmt.Sprintf("%s-%s-31", year, month) // Simplified, assumes 31 days
+	r, err := reporting.ParseRange(c.Request.URL.Query(), settings.GetLocation(h.db), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	var orders []models.Order
-	query := h.db.Where("user_id = ? AND created_at >= ? AND created_at <= ?", userID, startDate, endDate)
-	if err := query.Preload("OrderItems.Product").Preload("Stand").Find(&orders).Error; err != nil {
+	query := h.db.Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, r.From, r.To)
+	if err := query.Preload("OrderItems.Product").Preload("OrderItems.Options").Preload("OrderItems.SubstituteProduct").Preload("Stand").Order("created_at ASC").Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
 		return
 	}
 
-	// Calculate monthly summary
-	var totalOrders int64
-	var totalAmount float64
-	for _, order := range orders {
-		totalOrders++
-		totalAmount += order.TotalAmount
+	report, err := reporting.Orders(h.db.Where("user_id = ?", userID), r, reporting.Month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build summary"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"orders": orders,
 		"summary": gin.H{
-			"year": year,
-			"month": month,
-			"total_orders": totalOrders,
-			"total_amount": totalAmount,
+			"year":             r.From.Year(),
+			"month":            int(r.From.Month()),
+			"timezone":         report.Timezone,
+			"total_orders":     report.Summary.Orders,
+			"cancelled_orders": report.Summary.Cancelled,
+			"total_amount":     report.Summary.Amount,
 		},
 	})
 }
 
-// GetOrderReceipt generates a printable receipt for an order
+// GetSpendingReport summarises the current student's orders over from/to
+// (YYYY-MM-DD, default the current month) by granularity day, week, month
+// (default) or year
+func (h *OrderHandler) GetSpendingReport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	r, err := reporting.ParseRange(c.Request.URL.Query(), settings.GetLocation(h.db), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	granularity, err := reporting.ParseGranularity(c.Query("granularity"), reporting.Month)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := reporting.Orders(h.db.Where("user_id = ?", userID), r, granularity)
+	if errors.Is(err, reporting.ErrTooManyBuckets) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetOrderReceipt renders a receipt for an order of the current student.
+// Query format is html (default), pdf, text or escpos; width is 58 or 80 (mm).
 func (h *OrderHandler) GetOrderReceipt(c *gin.Context) {
 	orderID := c.Param("id")
 	userID, exists := c.Get("user_id")
@@ -191,87 +306,38 @@ func (h *OrderHandler) GetOrderReceipt(c *gin.Context) {
 	}
 
 	var order models.Order
-	if err := h.db.Where("id = ? AND user_id = ?", orderID, userID).Preload("OrderItems.Product").Preload("Stand").First(&order).Error; err != nil {
+	if err := h.db.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
 
-	// Generate HTML receipt
-	receiptHTML := h.generateReceiptHTML(order)
+	width, err := strconv.Atoi(c.DefaultQuery("width", "80"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": receipt.ErrUnknownWidth.Error()})
+		return
+	}
 
-	c.Header("Content-Type", "text/html")
-	c.String(http.StatusOK, receiptHTML)
-}
+	r, err := receipt.Build(h.db, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build receipt"})
+		return
+	}
+
+	format := c.DefaultQuery("format", receipt.HTML)
+	contentType, body, err := r.Render(format, width)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-// generateReceiptHTML creates HTML receipt for printing
-func (h *OrderHandler) generateReceiptHTML(order models.Order) string {
-	html := fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <title>Receipt - %s</title>
-    <style>
-        body { font-family: Arial, sans-serif; max-width: 400px; margin: 0 auto; padding: 20px; }
-        .header { text-align: center; border-bottom: 2px solid #000; padding-bottom: 10px; margin-bottom: 20px; }
-        .order-info { margin-bottom: 20px; }
-        .items { margin-bottom: 20px; }
-        .item { display: flex; justify-content: space-between; margin-bottom: 5px; }
-        .total { border-top: 1px solid #000; padding-top: 10px; font-weight: bold; }
-        .footer { text-align: center; margin-top: 20px; font-size: 12px; color: #666; }
-        @media print { body { margin: 0; } }
-    </style>
-</head>
-<body>
-    <div class="header">
-        <h2>%s</h2>
-        <p>Order Receipt</p>
-    </div>
-    
-    <div class="order-info">
-        <p><strong>Order Number:</strong> %s</p>
-        <p><strong>Date:</strong> %s</p>
-        <p><strong>Payment Method:</strong> %s</p>
-        <p><strong>Status:</strong> %s</p>
-    </div>
-    
-    <div class="items">
-        <h3>Items:</h3>`,
-		order.OrderNumber,
-		order.Stand.Name,
-		order.OrderNumber,
-		order.CreatedAt.Format("2006-01-02 15:04:05"),
-		order.PaymentMethod,
-		order.Status)
-
-	for _, item := range order.OrderItems {
-		html += fmt.Sprintf(`
-        <div class="item">
-            <span>%s (x%d)</span>
-            <span>Rp %.0f</span>
-        </div>`, item.Product.Name, item.Quantity, item.Subtotal)
-	}
-
-	html += fmt.Sprintf(`
-    </div>
-    
-    <div class="total">
-        <div class="item">
-            <span>Total Amount:</span>
-            <span>Rp %.0f</span>
-        </div>
-    </div>
-    
-    <div class="footer">
-        <p>Thank you for your order!</p>
-        <p>Generated on %s</p>
-    </div>
-</body>
-</html>`, order.TotalAmount, time.Now().Format("2006-01-02 15:04:05"))
-
-	return html
+	if filename := receipt.Filename(order.OrderNumber, format); filename != "" {
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+	}
+	c.Data(http.StatusOK, contentType, body)
 }
 
-// DeleteOrder deletes an order for the current student (soft delete)
+// DeleteOrder cancels an order of the current student, returning stock and
+// refunding any wallet payment. The order stays visible with status cancelled.
 func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 	id := c.Param("id")
 	userID, exists := c.Get("user_id")
@@ -286,17 +352,75 @@ func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 		return
 	}
 
-	// Only allow deletion of orders that are still pending or in request status
-	if order.Status != "payment_pending" && order.Status != "request" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Can only cancel orders that are pending or in request status"})
+	// Pre-orders can only be cancelled until the cutoff before their slot
+	if err := pickup.CheckCancel(h.db, order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pre-orders can no longer be cancelled this close to the pickup time"})
 		return
 	}
 
-	// Soft delete the order
-	if err := h.db.Delete(&order).Error; err != nil {
+	tx := h.db.Begin()
+	actor := orderstatus.Actor{UserID: userID.(uint), Role: orderstatus.RoleStudent}
+	if err := orderstatus.Transition(tx, &order, orderstatus.Cancelled, actor, "Cancelled by student"); err != nil {
+		tx.Rollback()
+		if errors.Is(err, orderstatus.ErrInvalidTransition) || errors.Is(err, orderstatus.ErrNotAllowed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Can only cancel orders that are pending or in request status"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
 		return
 	}
+	tx.Commit()
+
+	events.PublishOrder(events.OrderCancelled, order)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order cancelled successfully", "order": order})
+}
+
+// GetOrderTimeline returns the status history of an order of the current student
+func (h *OrderHandler) GetOrderTimeline(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var order models.Order
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order cancelled successfully"})
-}
\ No newline at end of file
+	timeline, err := orderstatus.Timeline(h.db, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order timeline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order_id":     order.ID,
+		"order_number": order.OrderNumber,
+		"status":       order.Status,
+		"timeline":     timeline,
+	})
+}
+
+// GetCheckout returns a cart checkout of the current student with its per-stand orders
+func (h *OrderHandler) GetCheckout(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var checkout models.Checkout
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).
+		Preload("Orders.OrderItems.Product").Preload("Orders.OrderItems.Options").Preload("Orders.OrderItems.SubstituteProduct").Preload("Orders.Stand").
+		First(&checkout).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Checkout not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, checkout)
+}
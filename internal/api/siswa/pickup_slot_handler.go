@@ -0,0 +1,53 @@
+package siswa
+
+import (
+	"net/http"
+	"swipeup-admin-v2/internal/app/pickup"
+	"swipeup-admin-v2/internal/app/settings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PickupSlotHandler lists the pre-order pickup slots of a stand for students
+type PickupSlotHandler struct {
+	db *gorm.DB
+}
+
+// NewPickupSlotHandler creates a new PickupSlotHandler instance
+func NewPickupSlotHandler(db *gorm.DB) *PickupSlotHandler {
+	return &PickupSlotHandler{db: db}
+}
+
+// GetPickupSlots returns a stand's slots with remaining capacity for a date (default today)
+func (h *PickupSlotHandler) GetPickupSlots(c *gin.Context) {
+	standID := parseUint(c.Param("stand_id"))
+	if standID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stand ID"})
+		return
+	}
+
+	loc := settings.GetLocation(h.db)
+	date := time.Now().In(loc)
+	if value := c.Query("date"); value != "" {
+		parsed, err := pickup.ParseDate(value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	slots, err := pickup.Slots(h.db, standID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pickup slots"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":           date.Format("2006-01-02"),
+		"max_days_ahead": pickup.MaxDaysAhead(h.db),
+		"slots":          slots,
+	})
+}
@@ -0,0 +1,213 @@
+package siswa
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/orderstatus"
+	"swipeup-admin-v2/internal/app/reviews"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReviewHandler handles ratings and reviews for students
+type ReviewHandler struct {
+	db *gorm.DB
+}
+
+// NewReviewHandler creates a new ReviewHandler instance
+func NewReviewHandler(db *gorm.DB) *ReviewHandler {
+	return &ReviewHandler{db: db}
+}
+
+// ReviewRequest is a rating with an optional comment
+type ReviewRequest struct {
+	Rating  int    `json:"rating" binding:"required"`
+	Comment string `json:"comment" binding:"max=500"`
+}
+
+// CreateReviews rates the items and the stand of a done order. Each order item
+// and the stand can be reviewed once per order.
+func (h *ReviewHandler) CreateReviews(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Stand *ReviewRequest `json:"stand"`
+		Items []struct {
+			OrderItemID uint `json:"order_item_id" binding:"required"`
+			ReviewRequest
+		} `json:"items"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Stand == nil && len(req.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide a stand review or item reviews"})
+		return
+	}
+
+	var order models.Order
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).Preload("OrderItems").First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if order.Status != orderstatus.Done {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only completed orders can be reviewed"})
+		return
+	}
+
+	items := make(map[uint]models.OrderItem, len(order.OrderItems))
+	for _, item := range order.OrderItems {
+		items[item.ID] = item
+	}
+
+	var created []models.Review
+	if req.Stand != nil {
+		if !reviews.ValidRating(req.Stand.Rating) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Rating must be between %d and %d", reviews.MinRating, reviews.MaxRating)})
+			return
+		}
+		created = append(created, models.Review{
+			OrderID: order.ID,
+			UserID:  order.UserID,
+			StandID: order.StandID,
+			Rating:  req.Stand.Rating,
+			Comment: strings.TrimSpace(req.Stand.Comment),
+		})
+	}
+	for _, itemReq := range req.Items {
+		item, found := items[itemReq.OrderItemID]
+		if !found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Order item %d is not part of this order", itemReq.OrderItemID)})
+			return
+		}
+		if !reviews.ValidRating(itemReq.Rating) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Rating must be between %d and %d", reviews.MinRating, reviews.MaxRating)})
+			return
+		}
+		productID := item.ProductID
+		created = append(created, models.Review{
+			OrderID:     order.ID,
+			OrderItemID: item.ID,
+			UserID:      order.UserID,
+			StandID:     order.StandID,
+			ProductID:   &productID,
+			Rating:      itemReq.Rating,
+			Comment:     strings.TrimSpace(itemReq.Comment),
+		})
+	}
+
+	// Refuse the whole request if anything in it was reviewed before
+	itemIDs := make([]uint, 0, len(created))
+	for _, review := range created {
+		itemIDs = append(itemIDs, review.OrderItemID)
+	}
+	var reviewed int64
+	if err := h.db.Unscoped().Model(&models.Review{}).Where("order_id = ? AND order_item_id IN ?", order.ID, itemIDs).Count(&reviewed).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing reviews"})
+		return
+	}
+	if reviewed > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This order has already been reviewed"})
+		return
+	}
+
+	if err := h.db.Create(&created).Error; err != nil {
+		// The unique index catches a concurrent duplicate
+		c.JSON(http.StatusConflict, gin.H{"error": "This order has already been reviewed"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Thank you for your review", "reviews": created})
+}
+
+// GetOrderReviews returns the student's reviews of an order
+func (h *ReviewHandler) GetOrderReviews(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var order models.Order
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	var orderReviews []models.Review
+	if err := h.db.Where("order_id = ?", order.ID).Preload("Product").Order("order_item_id").Find(&orderReviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+	c.JSON(http.StatusOK, orderReviews)
+}
+
+// GetProductReviews returns the visible reviews of a product, newest first
+func (h *ReviewHandler) GetProductReviews(c *gin.Context) {
+	var product models.Product
+	if err := h.db.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	var productReviews []models.Review
+	if err := h.db.Where("product_id = ? AND is_hidden = ?", product.ID, false).
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "name") }).
+		Order("created_at DESC").Limit(50).
+		Find(&productReviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+
+	stats, err := reviews.ProductStats(h.db, []uint{product.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ratings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id":     product.ID,
+		"rating_average": stats[product.ID].Average,
+		"rating_count":   stats[product.ID].Count,
+		"reviews":        productReviews,
+	})
+}
+
+// GetStands returns the active stands with their ratings
+func (h *ReviewHandler) GetStands(c *gin.Context) {
+	var stands []models.StandSettings
+	if err := h.db.Where("is_active = ?", true).Order("store_name").Find(&stands).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stands"})
+		return
+	}
+
+	standIDs := make([]uint, 0, len(stands))
+	for _, stand := range stands {
+		standIDs = append(standIDs, stand.StandID)
+	}
+	stats, err := reviews.StandStats(h.db, standIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ratings"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(stands))
+	for _, stand := range stands {
+		response = append(response, gin.H{
+			"stand_id":       stand.StandID,
+			"store_name":     stand.StoreName,
+			"rating_average": stats[stand.StandID].Average,
+			"rating_count":   stats[stand.StandID].Count,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,110 @@
+package siswa
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"swipeup-admin-v2/internal/app/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TopUpHandler handles bank transfer top-up requests for students
+type TopUpHandler struct {
+	db *gorm.DB
+}
+
+// NewTopUpHandler creates a new TopUpHandler instance
+func NewTopUpHandler(db *gorm.DB) *TopUpHandler {
+	return &TopUpHandler{db: db}
+}
+
+// CreateTopUpRequest submits a top-up request with a transfer proof image
+func (h *TopUpHandler) CreateTopUpRequest(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	amount, err := strconv.ParseFloat(c.PostForm("amount"), 64)
+	if err != nil || amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A positive amount is required"})
+		return
+	}
+
+	// Get uploaded file
+	file, err := c.FormFile("transfer_proof")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transfer proof file is required"})
+		return
+	}
+
+	// Validate file type (image only)
+	if !isValidImageFile(file.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only image files are allowed"})
+		return
+	}
+
+	// Generate unique filename
+	filename := fmt.Sprintf("topup_proof_%d_%d.png", userID, time.Now().UnixNano())
+	filepath := "uploads/topup_proofs/" + filename
+
+	// Save file
+	if err := c.SaveUploadedFile(file, filepath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save transfer proof"})
+		return
+	}
+
+	request := models.TopUpRequest{
+		UserID:   userID.(uint),
+		Amount:   amount,
+		ProofURL: filepath,
+		Status:   "pending",
+	}
+
+	if err := h.db.Create(&request).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create top-up request"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Top-up request submitted. Waiting for admin approval.",
+		"request": request,
+	})
+}
+
+// GetTopUpRequests returns the current student's top-up requests, newest first
+func (h *TopUpHandler) GetTopUpRequests(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var requests []models.TopUpRequest
+	if err := h.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch top-up requests"})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+// GetTopUpRequest returns a single top-up request owned by the current student
+func (h *TopUpHandler) GetTopUpRequest(c *gin.Context) {
+	id := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var request models.TopUpRequest
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).Preload("Transaction").First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Top-up request not found"})
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
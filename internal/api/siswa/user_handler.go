@@ -1,8 +1,14 @@
 package siswa
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/settings"
+	"swipeup-admin-v2/internal/app/statement"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -51,7 +57,8 @@ func (h *UserHandler) GetBalance(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"balance": user.Balance})
 }
 
-// GetTransactions returns the current user's transactions
+// GetTransactions returns the current user's transactions, newest first.
+// Supports from/to (YYYY-MM-DD), type, min_amount/max_amount and page/page_size filters.
 func (h *UserHandler) GetTransactions(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -59,10 +66,169 @@ func (h *UserHandler) GetTransactions(c *gin.Context) {
 		return
 	}
 
+	query := h.db.Model(&models.Transaction{}).Where("user_id = ?", userID)
+	loc := settings.GetLocation(h.db)
+
+	if from := c.Query("from"); from != "" {
+		start, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("created_at >= ?", start)
+	}
+	if to := c.Query("to"); to != "" {
+		end, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("created_at < ?", end.AddDate(0, 0, 1))
+	}
+	if txType := c.Query("type"); txType != "" {
+		types := strings.Split(txType, ",")
+		for _, t := range types {
+			if !validTransactionTypes[t] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction type: " + t})
+				return
+			}
+		}
+		query = query.Where("type IN ?", types)
+	}
+	if minAmount := c.Query("min_amount"); minAmount != "" {
+		value, err := strconv.ParseFloat(minAmount, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_amount"})
+			return
+		}
+		query = query.Where("ABS(amount) >= ?", value)
+	}
+	if maxAmount := c.Query("max_amount"); maxAmount != "" {
+		value, err := strconv.ParseFloat(maxAmount, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid max_amount"})
+			return
+		}
+		query = query.Where("ABS(amount) <= ?", value)
+	}
+
+	// Without paging parameters the response stays the plain array of every
+	// matching transaction that existing clients expect
+	_, hasPage := c.GetQuery("page")
+	_, hasPageSize := c.GetQuery("page_size")
+	if !hasPage && !hasPageSize {
+		var transactions []models.Transaction
+		if err := query.Preload("Order").Order("created_at DESC, id DESC").Find(&transactions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+			return
+		}
+		c.JSON(http.StatusOK, transactions)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		return
+	}
+
 	var transactions []models.Transaction
-	if err := h.db.Preload("Order").Where("user_id = ?", userID).Find(&transactions).Error; err != nil {
+	if err := query.Preload("Order").
+		Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&transactions).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
 		return
 	}
-	c.JSON(http.StatusOK, transactions)
+
+	c.JSON(http.StatusOK, gin.H{
+		"transactions": transactions,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// GetStatement exports the current user's account statement as CSV or PDF.
+// Defaults to the current month when from/to are not given.
+func (h *UserHandler) GetStatement(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	loc := settings.GetLocation(h.db)
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 1, 0)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	st, err := statement.Build(h.db, userID.(uint), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build statement"})
+		return
+	}
+
+	filename := fmt.Sprintf("statement_%s_%s", from.Format("20060102"), to.AddDate(0, 0, -1).Format("20060102"))
+
+	switch c.DefaultQuery("format", "json") {
+	case "csv":
+		c.Header("Content-Disposition", "attachment; filename="+filename+".csv")
+		c.Header("Content-Type", "text/csv")
+		if err := st.WriteCSV(c.Writer); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write statement"})
+		}
+	case "pdf":
+		schoolName := settings.GetString(h.db, "school_name", "Swipeup School")
+		c.Header("Content-Disposition", "attachment; filename="+filename+".pdf")
+		c.Data(http.StatusOK, "application/pdf", st.PDF(schoolName))
+	case "json":
+		c.JSON(http.StatusOK, st)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Use 'json', 'csv' or 'pdf'"})
+	}
+}
+
+var validTransactionTypes = map[string]bool{
+	"top_up":       true,
+	"purchase":     true,
+	"refund":       true,
+	"adjustment":   true,
+	"cash_out":     true,
+	"transfer_in":  true,
+	"transfer_out": true,
 }
@@ -0,0 +1,150 @@
+package siswa
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/options"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UsualOrderHandler handles saved "usual orders" for students. Checking one
+// out is done by CartHandler.CheckoutUsualOrder.
+type UsualOrderHandler struct {
+	db *gorm.DB
+}
+
+// NewUsualOrderHandler creates a new UsualOrderHandler instance
+func NewUsualOrderHandler(db *gorm.DB) *UsualOrderHandler {
+	return &UsualOrderHandler{db: db}
+}
+
+// UsualOrderItemRequest is one item of a usual order request
+type UsualOrderItemRequest struct {
+	ProductID uint   `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	OptionIDs []uint `json:"option_ids"`
+	Note      string `json:"note" binding:"max=255"`
+}
+
+// GetUsualOrders returns the student's usual orders
+func (h *UsualOrderHandler) GetUsualOrders(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var usualOrders []models.UsualOrder
+	if err := h.db.Where("user_id = ?", userID).
+		Preload("Items.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("name").
+		Find(&usualOrders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch usual orders"})
+		return
+	}
+	c.JSON(http.StatusOK, usualOrders)
+}
+
+// CreateUsualOrder saves a usual order from a list of items or from a past order (order_id)
+func (h *UsualOrderHandler) CreateUsualOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Name    string                  `json:"name" binding:"required,max=100"`
+		OrderID uint                    `json:"order_id"`
+		Items   []UsualOrderItemRequest `json:"items" binding:"dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.OrderID == 0) == (len(req.Items) == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either items or order_id"})
+		return
+	}
+
+	usual := models.UsualOrder{UserID: userID.(uint), Name: strings.TrimSpace(req.Name)}
+
+	if req.OrderID != 0 {
+		var order models.Order
+		if err := h.db.Where("id = ? AND user_id = ?", req.OrderID, userID).Preload("OrderItems.Options").First(&order).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		for _, item := range order.OrderItems {
+			usual.Items = append(usual.Items, models.UsualOrderItem{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				OptionIDs: options.KeyOf(options.OrderOptionIDs(item.Options)),
+				Note:      item.Note,
+			})
+		}
+	} else {
+		for _, item := range req.Items {
+			// Check the product and its options now; prices are taken at checkout
+			var product models.Product
+			if err := h.db.First(&product, item.ProductID).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+				return
+			}
+			selections, _, err := options.Resolve(h.db, product.ID, item.OptionIDs)
+			if err != nil {
+				var selectionErr *options.SelectionError
+				if errors.As(err, &selectionErr) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": product.Name + ": " + selectionErr.Error()})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check product options"})
+				return
+			}
+			usual.Items = append(usual.Items, models.UsualOrderItem{
+				ProductID: product.ID,
+				Quantity:  item.Quantity,
+				OptionIDs: options.Key(selections),
+				Note:      strings.TrimSpace(item.Note),
+			})
+		}
+	}
+
+	if err := h.db.Create(&usual).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save usual order"})
+		return
+	}
+	c.JSON(http.StatusCreated, usual)
+}
+
+// DeleteUsualOrder removes one of the student's usual orders
+func (h *UsualOrderHandler) DeleteUsualOrder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var usual models.UsualOrder
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&usual).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usual order not found"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("usual_order_id = ?", usual.ID).Delete(&models.UsualOrderItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&usual).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete usual order"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Usual order deleted successfully"})
+}
@@ -0,0 +1,55 @@
+package stand
+
+import (
+	"net/http"
+	"swipeup-admin-v2/internal/app/auth"
+	"swipeup-admin-v2/internal/app/events"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// EventHandler streams real-time order updates
+type EventHandler struct {
+	db *gorm.DB
+}
+
+// NewEventHandler creates a new EventHandler instance
+func NewEventHandler(db *gorm.DB) *EventHandler {
+	return &EventHandler{db: db}
+}
+
+// StreamEvents pushes new orders, payment proofs, status changes and cancellations for the current stand as Server-Sent Events.
+// Reconnecting clients send Last-Event-ID to receive the events they missed.
+func (h *EventHandler) StreamEvents(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	events.ServeStream(c.Writer, c.Request, events.Default, events.StandTopic(userID.(uint)))
+}
+
+// IssueTicket returns a single-use ticket for opening the event stream with
+// ?ticket=, since EventSource can't send the Authorization header
+func (h *EventHandler) IssueTicket(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	role, _ := c.Get("user_role")
+	username, _ := c.Get("username")
+
+	ticket, err := auth.IssueStreamTicket(userID.(uint), username.(string), role.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ticket":     ticket,
+		"expires_in": int(auth.StreamTicketTTL.Seconds()),
+	})
+}
@@ -0,0 +1,152 @@
+package stand
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"swipeup-admin-v2/internal/app/events"
+	"swipeup-admin-v2/internal/app/kitchen"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/orderstatus"
+	"swipeup-admin-v2/internal/app/settings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KitchenHandler handles the kitchen display of a stand
+type KitchenHandler struct {
+	db *gorm.DB
+}
+
+// NewKitchenHandler creates a new KitchenHandler instance
+func NewKitchenHandler(db *gorm.DB) *KitchenHandler {
+	return &KitchenHandler{db: db}
+}
+
+// GetKitchen returns the prep list of the stand's request and cooking orders
+// summed by product and options, plus each order's waiting time. Pre-orders
+// for a later day are left out until their day.
+func (h *KitchenHandler) GetKitchen(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	orders, err := pendingOrders(h.db, standID, kitchen.Statuses)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+		return
+	}
+
+	now := time.Now().In(settings.GetLocation(h.db))
+	year, month, day := now.Date()
+	tomorrow := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+
+	due := make([]models.Order, 0, len(orders))
+	orderIDs := make([]uint, 0, len(orders))
+	for _, order := range orders {
+		if order.ScheduledFor != nil && !order.ScheduledFor.Before(tomorrow) {
+			continue
+		}
+		due = append(due, order)
+		orderIDs = append(orderIDs, order.ID)
+	}
+
+	since, err := kitchen.WaitingSince(h.db, orderIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, kitchen.Build(due, since, now))
+}
+
+// BumpOrders moves a batch of orders to their next kitchen status: request
+// to cooking and cooking to ready. Orders that are no longer in either status
+// are skipped and reported instead of failing the whole batch.
+func (h *KitchenHandler) BumpOrders(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		OrderIDs []uint `json:"order_ids" binding:"required,min=1"`
+		Note     string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	type skippedOrder struct {
+		OrderID uint   `json:"order_id"`
+		Status  string `json:"status,omitempty"`
+		Reason  string `json:"reason"`
+	}
+	var bumped []models.Order
+	var skipped []skippedOrder
+
+	tx := h.db.Begin()
+
+	// Lock in ID order so concurrent bumps of overlapping batches can't deadlock
+	var orders []models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ? AND stand_id = ?", req.OrderIDs, standID).
+		Order("id ASC").
+		Find(&orders).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+		return
+	}
+
+	found := make(map[uint]bool, len(orders))
+	actor := orderstatus.Actor{UserID: standID.(uint), Role: orderstatus.RoleStand}
+	for i := range orders {
+		order := &orders[i]
+		found[order.ID] = true
+
+		to, ok := kitchen.Next(order.Status)
+		if !ok {
+			skipped = append(skipped, skippedOrder{OrderID: order.ID, Status: order.Status, Reason: "Order is not waiting in the kitchen"})
+			continue
+		}
+		if err := orderstatus.Transition(tx, order, to, actor, req.Note); err != nil {
+			if errors.Is(err, orderstatus.ErrInvalidTransition) || errors.Is(err, orderstatus.ErrNotAllowed) {
+				skipped = append(skipped, skippedOrder{OrderID: order.ID, Status: order.Status, Reason: "Cannot change order status from " + order.Status + " to " + to})
+				continue
+			}
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
+			return
+		}
+		bumped = append(bumped, *order)
+	}
+	tx.Commit()
+
+	for _, id := range req.OrderIDs {
+		if !found[id] {
+			skipped = append(skipped, skippedOrder{OrderID: id, Reason: "Order not found"})
+			found[id] = true
+		}
+	}
+
+	for _, order := range bumped {
+		events.PublishOrder(events.OrderStatusChanged, order)
+	}
+
+	result := make([]gin.H, 0, len(bumped))
+	for _, order := range bumped {
+		result = append(result, gin.H{"id": order.ID, "order_number": order.OrderNumber, "status": order.Status})
+	}
+	if skipped == nil {
+		skipped = []skippedOrder{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bumped": result, "skipped": skipped})
+}
@@ -44,7 +44,10 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 	}
 
 	var product models.Product
-	if err := h.db.Where("id = ? AND stand_id = ?", id, standID).Preload("Category").First(&product).Error; err != nil {
+	if err := h.db.Where("id = ? AND stand_id = ?", id, standID).Preload("Category").
+		Preload("OptionGroups", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order, id") }).
+		Preload("OptionGroups.Options", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order, id") }).
+		First(&product).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
@@ -0,0 +1,250 @@
+package stand
+
+import (
+	"errors"
+	"net/http"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/options"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// OptionGroupHandler handles product option groups (spice level, toppings) for stand admins
+type OptionGroupHandler struct {
+	db *gorm.DB
+}
+
+// NewOptionGroupHandler creates a new OptionGroupHandler instance
+func NewOptionGroupHandler(db *gorm.DB) *OptionGroupHandler {
+	return &OptionGroupHandler{db: db}
+}
+
+// OptionRequest is the body of one option in an option group request
+type OptionRequest struct {
+	ID         uint    `json:"id"` // Existing option to update; omit to add a new one
+	Name       string  `json:"name" binding:"required,max=100"`
+	PriceDelta float64 `json:"price_delta"`
+	IsActive   *bool   `json:"is_active"`
+	SortOrder  int     `json:"sort_order"`
+}
+
+// OptionGroupRequest is the body for creating or replacing an option group
+type OptionGroupRequest struct {
+	Name          string          `json:"name" binding:"required,max=100"`
+	SelectionType string          `json:"selection_type" binding:"required"` // single, multi
+	IsRequired    bool            `json:"is_required"`
+	MinSelect     int             `json:"min_select"`
+	MaxSelect     int             `json:"max_select"`
+	SortOrder     int             `json:"sort_order"`
+	Options       []OptionRequest `json:"options" binding:"required,min=1,dive"`
+}
+
+// GetOptionGroups returns the option groups of one of the stand's products
+func (h *OptionGroupHandler) GetOptionGroups(c *gin.Context) {
+	product, ok := h.findProduct(c)
+	if !ok {
+		return
+	}
+
+	var groups []models.ProductOptionGroup
+	if err := h.db.Where("product_id = ?", product.ID).
+		Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order, id") }).
+		Order("sort_order, id").
+		Find(&groups).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch option groups"})
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// CreateOptionGroup adds an option group with its options to a product
+func (h *OptionGroupHandler) CreateOptionGroup(c *gin.Context) {
+	product, ok := h.findProduct(c)
+	if !ok {
+		return
+	}
+
+	var req OptionGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group := models.ProductOptionGroup{ProductID: product.ID}
+	req.apply(&group)
+	for _, o := range req.Options {
+		group.Options = append(group.Options, o.option(models.ProductOption{IsActive: true}))
+	}
+
+	if err := options.Validate(group); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&group).Error; err != nil {
+			return err
+		}
+		return saveInactive(tx, group.Options)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create option group"})
+		return
+	}
+	c.JSON(http.StatusCreated, group)
+}
+
+// UpdateOptionGroup replaces an option group. Options sent with an id are
+// updated, options without one are added and options left out are removed.
+// Cart and order items keep their own copy of the chosen options.
+func (h *OptionGroupHandler) UpdateOptionGroup(c *gin.Context) {
+	product, ok := h.findProduct(c)
+	if !ok {
+		return
+	}
+
+	var group models.ProductOptionGroup
+	if err := h.db.Where("id = ? AND product_id = ?", c.Param("group_id"), product.ID).Preload("Options").First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Option group not found"})
+		return
+	}
+
+	var req OptionGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing := make(map[uint]models.ProductOption, len(group.Options))
+	for _, o := range group.Options {
+		existing[o.ID] = o
+	}
+
+	req.apply(&group)
+	kept := make(map[uint]bool)
+	group.Options = nil
+	for _, o := range req.Options {
+		base := models.ProductOption{GroupID: group.ID, IsActive: true}
+		if o.ID != 0 {
+			current, found := existing[o.ID]
+			if !found {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Option does not belong to this group"})
+				return
+			}
+			base = current
+			kept[o.ID] = true
+		}
+		group.Options = append(group.Options, o.option(base))
+	}
+
+	if err := options.Validate(group); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for id := range existing {
+			if !kept[id] {
+				if err := tx.Delete(&models.ProductOption{}, id).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for i := range group.Options {
+			if err := tx.Save(&group.Options[i]).Error; err != nil {
+				return err
+			}
+		}
+		if err := saveInactive(tx, group.Options); err != nil {
+			return err
+		}
+		return tx.Omit("Options").Save(&group).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update option group"})
+		return
+	}
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteOptionGroup removes an option group and its options from a product
+func (h *OptionGroupHandler) DeleteOptionGroup(c *gin.Context) {
+	product, ok := h.findProduct(c)
+	if !ok {
+		return
+	}
+
+	var group models.ProductOptionGroup
+	if err := h.db.Where("id = ? AND product_id = ?", c.Param("group_id"), product.ID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Option group not found"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", group.ID).Delete(&models.ProductOption{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&group).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete option group"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Option group deleted successfully"})
+}
+
+// findProduct loads the product from the :id parameter if it belongs to the stand
+func (h *OptionGroupHandler) findProduct(c *gin.Context) (*models.Product, bool) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return nil, false
+	}
+
+	var product models.Product
+	if err := h.db.Where("id = ? AND stand_id = ?", c.Param("id"), standID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch product"})
+		return nil, false
+	}
+	return &product, true
+}
+
+// saveInactive stores is_active = false for new options, which Create would
+// otherwise replace with the column default
+func saveInactive(tx *gorm.DB, productOptions []models.ProductOption) error {
+	for _, o := range productOptions {
+		if !o.IsActive {
+			if err := tx.Model(&models.ProductOption{}).Where("id = ?", o.ID).Update("is_active", false).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// apply copies the request's group settings onto group
+func (r OptionGroupRequest) apply(group *models.ProductOptionGroup) {
+	group.Name = r.Name
+	group.SelectionType = r.SelectionType
+	group.IsRequired = r.IsRequired
+	group.MinSelect = r.MinSelect
+	group.MaxSelect = r.MaxSelect
+	group.SortOrder = r.SortOrder
+}
+
+// option returns base with the request's fields applied
+func (r OptionRequest) option(base models.ProductOption) models.ProductOption {
+	base.Name = r.Name
+	base.PriceDelta = r.PriceDelta
+	base.SortOrder = r.SortOrder
+	if r.IsActive != nil {
+		base.IsActive = *r.IsActive
+	}
+	return base
+}
@@ -1,9 +1,23 @@
 package stand
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"swipeup-admin-v2/internal/app/events"
+	"swipeup-admin-v2/internal/app/fulfilment"
+	"swipeup-admin-v2/internal/app/inventory"
 	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/options"
+	"swipeup-admin-v2/internal/app/ordersearch"
+	"swipeup-admin-v2/internal/app/orderstatus"
+	"swipeup-admin-v2/internal/app/receipt"
+	"swipeup-admin-v2/internal/app/reporting"
+	"swipeup-admin-v2/internal/app/settings"
+	"swipeup-admin-v2/internal/app/wallet"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,70 +43,98 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 	}
 
 	var orders []models.Order
-	if err := h.db.Where("stand_id = ?", standID).Preload("User").Preload("OrderItems.Product").Find(&orders).Error; err != nil {
+	if err := h.db.Where("stand_id = ?", standID).Preload("User").Preload("OrderItems.Product").Preload("OrderItems.Options").Preload("OrderItems.SubstituteProduct").Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
 		return
 	}
 	c.JSON(http.StatusOK, orders)
 }
 
-// GetOrdersByMonth returns orders for the current stand filtered by month
-func (h *OrderHandler) GetOrdersByMonth(c *gin.Context) {
+// SearchOrders searches the current stand's orders, newest first, one page at
+// a time. See ordersearch.Parse for the filters, sorting and cursor.
+func (h *OrderHandler) SearchOrders(c *gin.Context) {
 	standID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	year := c.Query("year")
-	month := c.Query("month")
+	filter, err := ordersearch.Parse(c.Request.URL.Query(), settings.GetLocation(h.db))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.StandID = standID.(uint)
 
-	if year == "" || month == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Year and month parameters are required"})
+	page, err := ordersearch.Search(h.db.Preload("User").Preload("OrderItems.Product").Preload("OrderItems.Options").Preload("OrderItems.SubstituteProduct"), filter)
+	if errors.Is(err, ordersearch.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
 		return
 	}
 
-	// Build date range for the month
-	startDate := fmt.Sprintf("%s-%s-01", year, month)
-	endDate := fmt.Sprintf("%s-%s-31", year, month) // Simplified, assumes 31 days
+	c.JSON(http.StatusOK, gin.H{
+		"orders": page.Orders,
+		"pagination": gin.H{
+			"limit":       page.Limit,
+			"has_more":    page.HasMore,
+			"next_cursor": page.NextCursor,
+		},
+	})
+}
+
+// GetOrdersByMonth returns orders for the current stand filtered by month.
+// The month runs from midnight to midnight in the school time zone.
+func (h *OrderHandler) GetOrdersByMonth(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if c.Query("year") == "" || c.Query("month") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Year and month parameters are required"})
+		return
+	}
+	r, err := reporting.ParseRange(c.Request.URL.Query(), settings.GetLocation(h.db), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	var orders []models.Order
-	query := h.db.Where("stand_id = ? AND created_at >= ? AND created_at <= ?", standID, startDate, endDate)
-	if err := query.Preload("User").Preload("OrderItems.Product").Find(&orders).Error; err != nil {
+	query := h.db.Where("stand_id = ? AND created_at >= ? AND created_at < ?", standID, r.From, r.To)
+	if err := query.Preload("User").Preload("OrderItems.Product").Preload("OrderItems.Options").Preload("OrderItems.SubstituteProduct").Order("created_at ASC").Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
 		return
 	}
 
-	// Calculate monthly summary
-	var totalOrders int64
-	var totalRevenue float64
-	var completedOrders int64
-	var pendingOrders int64
-
-	for _, order := range orders {
-		totalOrders++
-		totalRevenue += order.TotalAmount
-		if order.Status == "done" {
-			completedOrders++
-		} else if order.Status == "payment_pending" || order.Status == "request" {
-			pendingOrders++
-		}
+	report, err := reporting.Orders(h.db.Where("stand_id = ?", standID), r, reporting.Month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build summary"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"orders": orders,
 		"summary": gin.H{
-			"year": year,
-			"month": month,
-			"total_orders": totalOrders,
-			"completed_orders": completedOrders,
-			"pending_orders": pendingOrders,
-			"total_revenue": totalRevenue,
+			"year":             r.From.Year(),
+			"month":            int(r.From.Month()),
+			"timezone":         report.Timezone,
+			"total_orders":     report.Summary.Orders,
+			"completed_orders": report.Summary.Completed,
+			"pending_orders":   report.Summary.Pending,
+			"cancelled_orders": report.Summary.Cancelled,
+			"total_revenue":    report.Summary.Amount,
 		},
 	})
 }
 
-// GetMonthlyRevenueRecap returns monthly revenue recap for the current stand
+// GetMonthlyRevenueRecap returns monthly revenue recap for the current stand.
+// Revenue leaves out cancelled orders.
 func (h *OrderHandler) GetMonthlyRevenueRecap(c *gin.Context) {
 	standID, exists := c.Get("user_id")
 	if !exists {
@@ -100,64 +142,81 @@ func (h *OrderHandler) GetMonthlyRevenueRecap(c *gin.Context) {
 		return
 	}
 
-	year := c.Query("year")
-	if year == "" {
-		year = fmt.Sprintf("%d", time.Now().Year())
-	}
-
-	// Get revenue data for each month of the year
-	var monthlyData []gin.H
-
-	for month := 1; month <= 12; month++ {
-		startDate := fmt.Sprintf("%s-%02d-01", year, month)
-		endDate := fmt.Sprintf("%s-%02d-31", year, month)
-
-		var result struct {
-			TotalOrders   int64   `json:"total_orders"`
-			TotalRevenue  float64 `json:"total_revenue"`
-			CompletedOrders int64 `json:"completed_orders"`
+	loc := settings.GetLocation(h.db)
+	year := time.Now().In(loc).Year()
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
 		}
+		year = parsed
+	}
 
-		h.db.Model(&models.Order{}).
-			Where("stand_id = ? AND created_at >= ? AND created_at <= ?", standID, startDate, endDate).
-			Select("COUNT(*) as total_orders, SUM(total_amount) as total_revenue, SUM(CASE WHEN status = 'done' THEN 1 ELSE 0 END) as completed_orders").
-			Scan(&result)
+	report, err := reporting.Orders(h.db.Where("stand_id = ?", standID), reporting.YearRange(year, loc), reporting.Month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build revenue recap"})
+		return
+	}
 
+	monthlyData := make([]gin.H, 0, len(report.Buckets))
+	for _, bucket := range report.Buckets {
 		monthlyData = append(monthlyData, gin.H{
-			"month": month,
-			"month_name": time.Month(month).String(),
-			"total_orders": result.TotalOrders,
-			"completed_orders": result.CompletedOrders,
-			"total_revenue": result.TotalRevenue,
+			"month":            int(bucket.Start.Month()),
+			"month_name":       bucket.Start.Month().String(),
+			"total_orders":     bucket.Orders,
+			"completed_orders": bucket.Completed,
+			"cancelled_orders": bucket.Cancelled,
+			"total_revenue":    bucket.Amount,
 		})
 	}
 
-	// Calculate yearly totals
-	var yearlyTotal struct {
-		TotalOrders   int64   `json:"total_orders"`
-		TotalRevenue  float64 `json:"total_revenue"`
-		CompletedOrders int64 `json:"completed_orders"`
-	}
-
-	startYear := fmt.Sprintf("%s-01-01", year)
-	endYear := fmt.Sprintf("%s-12-31", year)
-
-	h.db.Model(&models.Order{}).
-		Where("stand_id = ? AND created_at >= ? AND created_at <= ?", standID, startYear, endYear).
-		Select("COUNT(*) as total_orders, SUM(total_amount) as total_revenue, SUM(CASE WHEN status = 'done' THEN 1 ELSE 0 END) as completed_orders").
-		Scan(&yearlyTotal)
-
 	c.JSON(http.StatusOK, gin.H{
-		"year": year,
+		"year":         year,
+		"timezone":     report.Timezone,
 		"monthly_data": monthlyData,
 		"yearly_summary": gin.H{
-			"total_orders": yearlyTotal.TotalOrders,
-			"completed_orders": yearlyTotal.CompletedOrders,
-			"total_revenue": yearlyTotal.TotalRevenue,
+			"total_orders":     report.Summary.Orders,
+			"completed_orders": report.Summary.Completed,
+			"cancelled_orders": report.Summary.Cancelled,
+			"total_revenue":    report.Summary.Amount,
 		},
 	})
 }
 
+// GetOrderReport summarises the current stand's orders over from/to
+// (YYYY-MM-DD, default the current month) by granularity day (default),
+// week, month or year
+func (h *OrderHandler) GetOrderReport(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	r, err := reporting.ParseRange(c.Request.URL.Query(), settings.GetLocation(h.db), time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	granularity, err := reporting.ParseGranularity(c.Query("granularity"), reporting.Day)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := reporting.Orders(h.db.Where("stand_id = ?", standID), r, granularity)
+	if errors.Is(err, reporting.ErrTooManyBuckets) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
 // GetOrder returns a single order by ID
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	id := c.Param("id")
@@ -168,7 +227,7 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 	}
 
 	var order models.Order
-	if err := h.db.Where("id = ? AND stand_id = ?", id, standID).Preload("User").Preload("OrderItems.Product").First(&order).Error; err != nil {
+	if err := h.db.Where("id = ? AND stand_id = ?", id, standID).Preload("User").Preload("OrderItems.Product").Preload("OrderItems.Options").Preload("OrderItems.SubstituteProduct").First(&order).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
@@ -187,8 +246,10 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		UserID        uint     `json:"user_id" binding:"required"`
 		PaymentMethod string   `json:"payment_method" binding:"required"`
 		Items         []struct {
-			ProductID uint `json:"product_id" binding:"required"`
-			Quantity  int  `json:"quantity" binding:"required"`
+			ProductID uint   `json:"product_id" binding:"required"`
+			Quantity  int    `json:"quantity" binding:"required"`
+			OptionIDs []uint `json:"option_ids"`
+			Note      string `json:"note" binding:"max=255"`
 		} `json:"items" binding:"required"`
 	}
 
@@ -207,6 +268,14 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	// Start transaction
 	tx := h.db.Begin()
 
+	// Generate order number
+	orderNumber, err := numbering.Next(tx, numbering.Order, standID.(uint))
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate order number"})
+		return
+	}
+
 	// Determine initial status based on payment method
 	initialStatus := "payment_pending"
 	if req.PaymentMethod == "cash" {
@@ -215,7 +284,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 
 	// Create order
 	order := models.Order{
-		OrderNumber:   "ORD-" + time.Now().Format("20060102150405"),
+		OrderNumber:   orderNumber,
 		UserID:        req.UserID,
 		Status:        initialStatus,
 		PaymentMethod: req.PaymentMethod,
@@ -226,25 +295,43 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var totalAmount float64
 	orderItems := make([]models.OrderItem, 0, len(req.Items))
 
+	// Reserve stock for all items atomically
+	lines := make([]inventory.Line, 0, len(req.Items))
 	for _, item := range req.Items {
-		var product models.Product
-		if err := tx.Where("id = ? AND stand_id = ?", item.ProductID, standID).First(&product).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		lines = append(lines, inventory.Line{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	products, err := inventory.Reserve(tx, lines, standID.(uint))
+	if err != nil {
+		tx.Rollback()
+		var stockErr *inventory.StockError
+		if errors.As(err, &stockErr) {
+			status := http.StatusBadRequest
+			if errors.Is(err, inventory.ErrProductNotFound) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{"error": stockErr.Message()})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product stock"})
+		return
+	}
+
+	for _, item := range req.Items {
+		product := products[item.ProductID]
 
-		if product.Stock < item.Quantity {
+		// Calculate discounted price with the chosen options
+		selections, delta, err := options.Resolve(tx, product.ID, item.OptionIDs)
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient stock for product: " + product.Name})
+			var selectionErr *options.SelectionError
+			if errors.As(err, &selectionErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": product.Name + ": " + selectionErr.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check product options"})
 			return
 		}
-
-		// Calculate discounted price
-		price := product.Price
-		if product.Discount > 0 {
-			price = product.Price - (product.Price * product.Discount / 100)
-		}
+		price := options.UnitPrice(product, delta)
 
 		subtotal := float64(item.Quantity) * price
 		totalAmount += subtotal
@@ -254,16 +341,10 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 			Quantity:  item.Quantity,
 			Price:     price,
 			Subtotal:  subtotal,
+			Options:   options.OrderItemOptions(selections),
+			Note:      strings.TrimSpace(item.Note),
 		}
 		orderItems = append(orderItems, orderItem)
-
-		// Update product stock
-		product.Stock -= item.Quantity
-		if err := tx.Save(&product).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product stock"})
-			return
-		}
 	}
 
 	// Check user balance (only for card payments)
@@ -293,41 +374,147 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		}
 	}
 
+	if err := orderstatus.RecordCreated(tx, &order, orderstatus.Actor{UserID: standID.(uint), Role: orderstatus.RoleStand}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+		return
+	}
+
 	// Deduct user balance (only for card payments)
 	if req.PaymentMethod == "card" {
-		balanceBefore := user.Balance
-		user.Balance -= totalAmount
-		if err := tx.Save(&user).Error; err != nil {
+		transactionNumber, err := numbering.Next(tx, numbering.Purchase, standID.(uint))
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user balance"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate transaction number"})
 			return
 		}
 
-		// Create transaction record
-		transaction := models.Transaction{
-			TransactionNumber: "PUR-" + time.Now().Format("20060102150405"),
-			UserID:           user.ID,
-			Type:             "purchase",
-			Amount:           totalAmount,
-			BalanceBefore:    balanceBefore,
-			BalanceAfter:     user.Balance,
-			Description:      "Purchase: " + order.OrderNumber,
-			OrderID:          &order.ID,
-		}
-
-		if err := tx.Create(&transaction).Error; err != nil {
+		if _, err := wallet.Debit(tx, wallet.Entry{
+			UserID:            user.ID,
+			TransactionNumber: transactionNumber,
+			Type:              "purchase",
+			Amount:            totalAmount,
+			Description:       "Purchase: " + order.OrderNumber,
+			OrderID:           &order.ID,
+		}); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaction"})
+			if errors.Is(err, wallet.ErrInsufficientBalance) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user balance"})
 			return
 		}
 	}
 
 	tx.Commit()
 
+	events.PublishOrder(events.OrderCreated, order)
+
 	c.JSON(http.StatusCreated, order)
 }
 
-// UpdateOrderStatus updates order status
+// UpdateOrderItem marks an order item unavailable (all or some units) or
+// substituted while the order is pending or cooking. The order total is
+// lowered, the difference refunded and the student notified.
+func (h *OrderHandler) UpdateOrderItem(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Action              string `json:"action" binding:"required"` // unavailable, substituted
+		Quantity            int    `json:"quantity"`                  // Units unavailable, all when omitted
+		SubstituteProductID uint   `json:"substitute_product_id"`
+		Note                string `json:"note" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var itemID uint
+	if _, err := fmt.Sscanf(c.Param("item_id"), "%d", &itemID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order item ID"})
+		return
+	}
+
+	var order models.Order
+	if err := h.db.Where("id = ? AND stand_id = ?", c.Param("id"), standID).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	tx := h.db.Begin()
+	actor := orderstatus.Actor{UserID: standID.(uint), Role: orderstatus.RoleStand}
+	result, err := fulfilment.Apply(tx, &order, fulfilment.Change{
+		ItemID:              itemID,
+		Action:              req.Action,
+		Quantity:            req.Quantity,
+		SubstituteProductID: req.SubstituteProductID,
+		Note:                strings.TrimSpace(req.Note),
+	}, actor)
+	if err != nil {
+		tx.Rollback()
+		var stockErr *inventory.StockError
+		switch {
+		case errors.Is(err, fulfilment.ErrItemNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+		case errors.Is(err, fulfilment.ErrOrderNotChangeable), errors.Is(err, fulfilment.ErrAlreadyChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, fulfilment.ErrInvalidChange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.As(err, &stockErr):
+			c.JSON(http.StatusBadRequest, gin.H{"error": stockErr.Message()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order item"})
+		}
+		return
+	}
+	tx.Commit()
+
+	if result.Cancelled {
+		events.PublishOrder(events.OrderCancelled, order)
+	} else {
+		events.PublishOrder(events.OrderItemsChanged, order)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Order item updated successfully",
+		"order":   order,
+		"result":  result,
+	})
+}
+
+// MarkRefundPaid records that the stand paid a pending cash refund back to the student
+func (h *OrderHandler) MarkRefundPaid(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var order models.Order
+	if err := h.db.Where("id = ? AND stand_id = ?", c.Param("id"), standID).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	if err := fulfilment.MarkRefunded(h.db, &order); err != nil {
+		if errors.Is(err, fulfilment.ErrInvalidChange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Order has no pending refund"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update refund"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Refund marked as paid", "order": order})
+}
+
+// UpdateOrderStatus moves an order to a new status through the order state machine
 func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	id := c.Param("id")
 	standID, exists := c.Get("user_id")
@@ -338,6 +525,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 
 	var req struct {
 		Status string `json:"status" binding:"required"`
+		Note   string `json:"note"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -346,14 +534,7 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 	}
 
 	// Validate status
-	validStatuses := map[string]bool{
-		"payment_pending": true,
-		"request":         true,
-		"cooking":         true,
-		"done":            true,
-		"cancelled":       true,
-	}
-	if !validStatuses[req.Status] {
+	if !orderstatus.Valid(req.Status) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
 		return
 	}
@@ -364,16 +545,103 @@ func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	order.Status = req.Status
-	if err := h.db.Save(&order).Error; err != nil {
+	tx := h.db.Begin()
+	actor := orderstatus.Actor{UserID: standID.(uint), Role: orderstatus.RoleStand}
+	if err := orderstatus.Transition(tx, &order, req.Status, actor, req.Note); err != nil {
+		tx.Rollback()
+		if errors.Is(err, orderstatus.ErrInvalidTransition) || errors.Is(err, orderstatus.ErrNotAllowed) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Cannot change order status from " + order.Status + " to " + req.Status,
+				"allowed": orderstatus.Allowed(order.Status, orderstatus.RoleStand),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
 		return
 	}
+	tx.Commit()
+
+	if order.Status == orderstatus.Cancelled {
+		events.PublishOrder(events.OrderCancelled, order)
+	} else {
+		events.PublishOrder(events.OrderStatusChanged, order)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully", "status": order.Status})
 }
 
-// DeleteOrder deletes an order for the current stand (soft delete)
+// GetOrderTimeline returns the status history of an order of the current stand
+func (h *OrderHandler) GetOrderTimeline(c *gin.Context) {
+	id := c.Param("id")
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var order models.Order
+	if err := h.db.Where("id = ? AND stand_id = ?", id, standID).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	timeline, err := orderstatus.Timeline(h.db.Preload("ChangedBy"), order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order timeline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order_id":     order.ID,
+		"order_number": order.OrderNumber,
+		"status":       order.Status,
+		"allowed":      orderstatus.Allowed(order.Status, orderstatus.RoleStand),
+		"timeline":     timeline,
+	})
+}
+
+// GetOrderReceipt renders a receipt for an order of the current stand, e.g.
+// ?format=escpos&width=58 for the counter's thermal printer
+func (h *OrderHandler) GetOrderReceipt(c *gin.Context) {
+	id := c.Param("id")
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var order models.Order
+	if err := h.db.Where("id = ? AND stand_id = ?", id, standID).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	width, err := strconv.Atoi(c.DefaultQuery("width", "80"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": receipt.ErrUnknownWidth.Error()})
+		return
+	}
+
+	r, err := receipt.Build(h.db, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build receipt"})
+		return
+	}
+
+	format := c.DefaultQuery("format", receipt.HTML)
+	contentType, body, err := r.Render(format, width)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if filename := receipt.Filename(order.OrderNumber, format); filename != "" {
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// DeleteOrder cancels an order for the current stand and then soft deletes it
 func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 	id := c.Param("id")
 	standID, exists := c.Get("user_id")
@@ -389,16 +657,29 @@ func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 	}
 
 	// Only allow deletion of orders that haven't been completed or cancelled
-	if order.Status == "done" || order.Status == "cancelled" {
+	if order.Status == orderstatus.Done || order.Status == orderstatus.Cancelled {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete completed or cancelled orders"})
 		return
 	}
 
+	// Cancel first so stock is returned and wallet payments are refunded
+	tx := h.db.Begin()
+	actor := orderstatus.Actor{UserID: standID.(uint), Role: orderstatus.RoleStand}
+	if err := orderstatus.Transition(tx, &order, orderstatus.Cancelled, actor, "Order deleted by stand"); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
+		return
+	}
+
 	// Soft delete the order
-	if err := h.db.Delete(&order).Error; err != nil {
+	if err := tx.Delete(&order).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete order"})
 		return
 	}
+	tx.Commit()
+
+	events.PublishOrder(events.OrderCancelled, order)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Order deleted successfully"})
 }
@@ -411,10 +692,100 @@ func (h *OrderHandler) GetPendingOrders(c *gin.Context) {
 		return
 	}
 
-	var orders []models.Order
-	if err := h.db.Where("stand_id = ? AND status IN ?", standID, []string{"payment_pending", "request", "cooking"}).Preload("User").Preload("OrderItems.Product").Find(&orders).Error; err != nil {
+	orders, err := pendingOrders(h.db, standID, []string{"payment_pending", "request", "cooking", "ready"})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pending orders"})
 		return
 	}
 	c.JSON(http.StatusOK, orders)
 }
+
+// pendingOrders loads a stand's orders in the given statuses with everything
+// the order queue and the kitchen display show, oldest first
+func pendingOrders(db *gorm.DB, standID interface{}, statuses []string) ([]models.Order, error) {
+	var orders []models.Order
+	err := db.Where("stand_id = ? AND status IN ?", standID, statuses).
+		Preload("User").
+		Preload("OrderItems.Product").
+		Preload("OrderItems.Options").
+		Preload("OrderItems.SubstituteProduct").
+		Order("created_at ASC").
+		Find(&orders).Error
+	return orders, err
+}
+
+// ConfirmPickup marks a ready order as collected by scanning the student's
+// pickup QR code or tapping their card. With a card, order_id picks one of
+// several ready orders; without it all of the student's ready orders are completed.
+func (h *OrderHandler) ConfirmPickup(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		PickupCode string `json:"pickup_code"`
+		RFIDCard   string `json:"rfid_card"`
+		OrderID    uint   `json:"order_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := h.db.Where("stand_id = ? AND status = ?", standID, orderstatus.Ready)
+	method := "qr"
+	switch {
+	case req.PickupCode != "":
+		query = query.Where("pickup_code = ?", strings.ToUpper(req.PickupCode))
+	case req.RFIDCard != "":
+		var student models.User
+		if err := h.db.Where("rf_id_card = ? AND is_active = ?", req.RFIDCard, true).First(&student).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Card not recognised"})
+			return
+		}
+		query = query.Where("user_id = ?", student.ID)
+		if req.OrderID != 0 {
+			query = query.Where("id = ?", req.OrderID)
+		}
+		method = "card"
+	case req.OrderID != 0:
+		query = query.Where("id = ?", req.OrderID)
+		method = "manual"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pickup_code, rfid_card or order_id is required"})
+		return
+	}
+
+	var orders []models.Order
+	if err := query.Order("created_at ASC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+		return
+	}
+	if len(orders) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No order ready for pickup"})
+		return
+	}
+
+	tx := h.db.Begin()
+	actor := orderstatus.Actor{UserID: standID.(uint), Role: orderstatus.RoleStand}
+	for i := range orders {
+		if err := orderstatus.ConfirmPickup(tx, &orders[i], actor, method); err != nil {
+			tx.Rollback()
+			if errors.Is(err, orderstatus.ErrInvalidTransition) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Order " + orders[i].OrderNumber + " is no longer ready for pickup"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm pickup"})
+			return
+		}
+	}
+	tx.Commit()
+
+	for _, order := range orders {
+		events.PublishOrder(events.OrderStatusChanged, order)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pickup confirmed", "orders": orders})
+}
@@ -0,0 +1,218 @@
+package stand
+
+import (
+	"net/http"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/pickup"
+	"swipeup-admin-v2/internal/app/settings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PickupSlotHandler handles pre-order pickup slots for stand admins
+type PickupSlotHandler struct {
+	db *gorm.DB
+}
+
+// NewPickupSlotHandler creates a new PickupSlotHandler instance
+func NewPickupSlotHandler(db *gorm.DB) *PickupSlotHandler {
+	return &PickupSlotHandler{db: db}
+}
+
+// PickupSlotRequest represents the request body for creating or updating a slot
+type PickupSlotRequest struct {
+	Name      string `json:"name" binding:"required"`
+	StartTime string `json:"start_time" binding:"required"` // HH:MM
+	Capacity  int    `json:"capacity" binding:"required,min=1"`
+	IsActive  *bool  `json:"is_active"`
+}
+
+// GetPickupSlots returns the current stand's pickup slots
+func (h *PickupSlotHandler) GetPickupSlots(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var slots []models.PickupSlot
+	if err := h.db.Where("stand_id = ?", standID).Order("start_time ASC").Find(&slots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pickup slots"})
+		return
+	}
+	c.JSON(http.StatusOK, slots)
+}
+
+// CreatePickupSlot adds a pickup slot for the current stand
+func (h *PickupSlotHandler) CreatePickupSlot(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req PickupSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := pickup.ParseStartTime(req.StartTime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format. Use HH:MM"})
+		return
+	}
+
+	slot := models.PickupSlot{
+		StandID:   standID.(uint),
+		Name:      req.Name,
+		StartTime: req.StartTime,
+		Capacity:  req.Capacity,
+		IsActive:  true,
+	}
+	if req.IsActive != nil {
+		slot.IsActive = *req.IsActive
+	}
+
+	if err := h.db.Create(&slot).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pickup slot"})
+		return
+	}
+	c.JSON(http.StatusCreated, slot)
+}
+
+// UpdatePickupSlot updates a pickup slot of the current stand.
+// Lowering the capacity does not affect orders that are already booked.
+func (h *PickupSlotHandler) UpdatePickupSlot(c *gin.Context) {
+	id := c.Param("id")
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req PickupSlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := pickup.ParseStartTime(req.StartTime); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format. Use HH:MM"})
+		return
+	}
+
+	var slot models.PickupSlot
+	if err := h.db.Where("id = ? AND stand_id = ?", id, standID).First(&slot).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pickup slot not found"})
+		return
+	}
+
+	slot.Name = req.Name
+	slot.StartTime = req.StartTime
+	slot.Capacity = req.Capacity
+	if req.IsActive != nil {
+		slot.IsActive = *req.IsActive
+	}
+
+	if err := h.db.Save(&slot).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pickup slot"})
+		return
+	}
+	c.JSON(http.StatusOK, slot)
+}
+
+// DeletePickupSlot removes a pickup slot that has no upcoming orders
+func (h *PickupSlotHandler) DeletePickupSlot(c *gin.Context) {
+	id := c.Param("id")
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var slot models.PickupSlot
+	if err := h.db.Where("id = ? AND stand_id = ?", id, standID).First(&slot).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pickup slot not found"})
+		return
+	}
+
+	var upcoming int64
+	if err := h.db.Model(&models.Order{}).
+		Where("pickup_slot_id = ? AND scheduled_for > ? AND status NOT IN ?", slot.ID, time.Now(), []string{"done", "cancelled"}).
+		Count(&upcoming).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check pickup slot orders"})
+		return
+	}
+	if upcoming > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pickup slot has upcoming orders, deactivate it instead"})
+		return
+	}
+
+	if err := h.db.Delete(&slot).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete pickup slot"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pickup slot deleted successfully"})
+}
+
+// GetScheduledOrders returns the stand's pre-orders for a day grouped by pickup slot
+func (h *PickupSlotHandler) GetScheduledOrders(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	loc := settings.GetLocation(h.db)
+	date := time.Now().In(loc)
+	if value := c.Query("date"); value != "" {
+		parsed, err := pickup.ParseDate(value, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+	dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+
+	slots, err := pickup.Slots(h.db, standID.(uint), dayStart)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pickup slots"})
+		return
+	}
+
+	var orders []models.Order
+	if err := h.db.Where("stand_id = ? AND scheduled_for >= ? AND scheduled_for < ? AND status <> ?", standID, dayStart, dayStart.AddDate(0, 0, 1), "cancelled").
+		Preload("User").Preload("OrderItems.Product").Preload("OrderItems.Options").Preload("OrderItems.SubstituteProduct").
+		Order("scheduled_for ASC, created_at ASC").
+		Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scheduled orders"})
+		return
+	}
+
+	bySlot := make(map[uint][]models.Order)
+	for _, order := range orders {
+		if order.PickupSlotID != nil {
+			bySlot[*order.PickupSlotID] = append(bySlot[*order.PickupSlotID], order)
+		}
+	}
+
+	groups := make([]gin.H, 0, len(slots))
+	for _, slot := range slots {
+		slotOrders := bySlot[slot.ID]
+		if slotOrders == nil {
+			slotOrders = []models.Order{}
+		}
+		groups = append(groups, gin.H{
+			"slot":   slot,
+			"orders": slotOrders,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":  dayStart.Format("2006-01-02"),
+		"slots": groups,
+	})
+}
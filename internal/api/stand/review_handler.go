@@ -0,0 +1,115 @@
+package stand
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/notify"
+	"swipeup-admin-v2/internal/app/reviews"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReviewHandler handles reviews of a stand and its products for stand admins
+type ReviewHandler struct {
+	db *gorm.DB
+}
+
+// NewReviewHandler creates a new ReviewHandler instance
+func NewReviewHandler(db *gorm.DB) *ReviewHandler {
+	return &ReviewHandler{db: db}
+}
+
+// GetReviews returns the stand's reviews, newest first. Hidden reviews are
+// included with is_hidden set. Filters: product_id, rating, unreplied=true.
+func (h *ReviewHandler) GetReviews(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := h.db.Where("stand_id = ?", standID).
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "name") }).
+		Preload("Product").
+		Order("created_at DESC")
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if rating := c.Query("rating"); rating != "" {
+		query = query.Where("rating = ?", rating)
+	}
+	if c.Query("unreplied") == "true" {
+		query = query.Where("reply = ''")
+	}
+
+	var standReviews []models.Review
+	if err := query.Find(&standReviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+
+	stats, err := reviews.StandStats(h.db, []uint{standID.(uint)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ratings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rating_average": stats[standID.(uint)].Average,
+		"rating_count":   stats[standID.(uint)].Count,
+		"reviews":        standReviews,
+	})
+}
+
+// ReplyToReview sets or replaces the stand's public reply to a review and notifies the student
+func (h *ReviewHandler) ReplyToReview(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Reply string `json:"reply" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var review models.Review
+	if err := h.db.Where("id = ? AND stand_id = ?", c.Param("id"), standID).First(&review).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+		return
+	}
+
+	now := time.Now()
+	review.Reply = strings.TrimSpace(req.Reply)
+	review.RepliedAt = &now
+
+	tx := h.db.Begin()
+	if err := tx.Model(&review).Updates(map[string]interface{}{"reply": review.Reply, "replied_at": now}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reply"})
+		return
+	}
+
+	if err := notify.Queue(tx, notify.Preference(tx, review.UserID), notify.Message{
+		UserID:  review.UserID,
+		Type:    notify.ReviewReply,
+		Title:   "The stand replied to your review",
+		Message: review.Reply,
+		Data:    map[string]interface{}{"review_id": review.ID, "order_id": review.OrderID},
+	}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reply"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,70 @@
+package stand
+
+import (
+	"net/http"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/settlement"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SettlementHandler handles settlement statements for stand admins
+type SettlementHandler struct {
+	db *gorm.DB
+}
+
+// NewSettlementHandler creates a new SettlementHandler instance
+func NewSettlementHandler(db *gorm.DB) *SettlementHandler {
+	return &SettlementHandler{db: db}
+}
+
+// GetSettlements returns the current stand's closed settlement statements
+func (h *SettlementHandler) GetSettlements(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var settlements []models.Settlement
+	if err := h.db.Where("stand_id = ?", standID).Order("period_end DESC").Find(&settlements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settlements"})
+		return
+	}
+	c.JSON(http.StatusOK, settlements)
+}
+
+// GetSettlement returns a single settlement statement with its lines
+func (h *SettlementHandler) GetSettlement(c *gin.Context) {
+	id := c.Param("id")
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var statement models.Settlement
+	if err := h.db.Where("id = ? AND stand_id = ?", id, standID).Preload("Lines").First(&statement).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Settlement not found"})
+		return
+	}
+	c.JSON(http.StatusOK, statement)
+}
+
+// GetCurrentSettlement returns the running totals of the stand's open period
+func (h *SettlementHandler) GetCurrentSettlement(c *gin.Context) {
+	standID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	current, err := settlement.Compute(h.db, standID.(uint), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute open period"})
+		return
+	}
+	c.JSON(http.StatusOK, current)
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// StreamTicketTTL is how long a stream ticket can be redeemed after it is issued
+const StreamTicketTTL = 30 * time.Second
+
+// TicketStore holds single-use tickets for event streams. Browsers' EventSource
+// can't set headers, so it passes a ticket in the URL instead of the token.
+type TicketStore struct {
+	tickets map[string]UserInfo
+	mu      sync.Mutex
+}
+
+// NewTicketStore creates a new ticket store
+func NewTicketStore() *TicketStore {
+	return &TicketStore{
+		tickets: make(map[string]UserInfo),
+	}
+}
+
+// Issue creates a ticket for the user that expires after StreamTicketTTL
+func (ts *TicketStore) Issue(userInfo UserInfo) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	ticket := hex.EncodeToString(buf)
+	userInfo.Expiry = time.Now().Add(StreamTicketTTL)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	// Drop expired tickets that were never redeemed
+	now := time.Now()
+	for t, info := range ts.tickets {
+		if now.After(info.Expiry) {
+			delete(ts.tickets, t)
+		}
+	}
+	ts.tickets[ticket] = userInfo
+	return ticket, nil
+}
+
+// Redeem returns the ticket's user and removes the ticket, so it works only once
+func (ts *TicketStore) Redeem(ticket string) (UserInfo, bool) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	userInfo, exists := ts.tickets[ticket]
+	if !exists {
+		return UserInfo{}, false
+	}
+	delete(ts.tickets, ticket)
+	if time.Now().After(userInfo.Expiry) {
+		return UserInfo{}, false
+	}
+	return userInfo, true
+}
+
+// Global stream ticket store instance
+var TicketStoreInstance = NewTicketStore()
+
+// IssueStreamTicket issues a single-use event stream ticket for the user
+func IssueStreamTicket(userID uint, username, role string) (string, error) {
+	return TicketStoreInstance.Issue(UserInfo{UserID: userID, Username: username, Role: role})
+}
+
+// RedeemStreamTicket validates and consumes an event stream ticket
+func RedeemStreamTicket(ticket string) (UserInfo, bool) {
+	return TicketStoreInstance.Redeem(ticket)
+}
@@ -48,6 +48,17 @@ func (ts *TokenStore) RemoveToken(token string) {
 	delete(ts.tokens, token)
 }
 
+// RemoveUserTokens removes every token issued to a user
+func (ts *TokenStore) RemoveUserTokens(userID uint) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for token, userInfo := range ts.tokens {
+		if userInfo.UserID == userID {
+			delete(ts.tokens, token)
+		}
+	}
+}
+
 // CleanupExpiredTokens removes expired tokens
 func (ts *TokenStore) CleanupExpiredTokens() {
 	ts.mu.Lock()
@@ -97,4 +108,9 @@ func ValidateToken(token string) (UserInfo, bool) {
 // RemoveToken removes a token from the store
 func RemoveToken(token string) {
 	TokenStoreInstance.RemoveToken(token)
+}
+
+// RevokeUserTokens logs a user out of every session
+func RevokeUserTokens(userID uint) {
+	TokenStoreInstance.RemoveUserTokens(userID)
 }
\ No newline at end of file
@@ -0,0 +1,207 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+)
+
+// Event types
+const (
+	OrderCreated         = "order_created"
+	OrderStatusChanged   = "order_status_changed"
+	OrderCancelled       = "order_cancelled"
+	PaymentProofUploaded = "payment_proof_uploaded"
+	OrderItemsChanged    = "order_items_changed" // A stand marked items unavailable or substituted
+	Resync               = "resync"              // Sent when missed events are no longer buffered
+)
+
+// heartbeatInterval keeps idle connections open through proxies
+const heartbeatInterval = 15 * time.Second
+
+// Event is a message published on a topic
+type Event struct {
+	ID        uint64      `json:"id"`
+	Topic     string      `json:"-"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Bus is an in-process publish/subscribe bus that keeps the latest events
+// so reconnecting clients can catch up from their last event ID
+type Bus struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []Event // Ring buffer, oldest first once full
+	historySize int
+	subscribers map[string]map[chan Event]struct{}
+}
+
+// NewBus creates a bus that buffers up to historySize events
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		// Start from the clock so IDs keep increasing across server restarts
+		nextID:      uint64(time.Now().UnixMilli()) * 1000,
+		historySize: historySize,
+		subscribers: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+// Default is the bus used by the API handlers
+var Default = NewBus(1000)
+
+// UserTopic is the topic for a student's own orders
+func UserTopic(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// StandTopic is the topic for a stand's orders
+func StandTopic(standID uint) string {
+	return fmt.Sprintf("stand:%d", standID)
+}
+
+// Publish sends an event to all subscribers of the topic and buffers it
+func (b *Bus) Publish(topic, eventType string, data interface{}) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event := Event{ID: b.nextID, Topic: topic, Type: eventType, Data: data, CreatedAt: time.Now()}
+
+	b.history = append(b.history, event)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for ch := range b.subscribers[topic] {
+		select {
+		case ch <- event:
+		default:
+			// Slow subscriber: drop it, the client reconnects and replays from its last ID
+			delete(b.subscribers[topic], ch)
+			close(ch)
+		}
+	}
+	return event
+}
+
+// Subscribe registers a subscriber for the topic. Buffered events after
+// lastEventID are returned for replay; complete is false when some of them
+// were already dropped from the buffer. Call cancel when done.
+func (b *Bus) Subscribe(topic string, lastEventID uint64) (replay []Event, events <-chan Event, complete bool, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if lastEventID > 0 {
+		if len(b.history) > 0 && b.history[0].ID > lastEventID+1 {
+			complete = false
+		}
+		for _, event := range b.history {
+			if event.Topic == topic && event.ID > lastEventID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, 64)
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[chan Event]struct{})
+	}
+	b.subscribers[topic][ch] = struct{}{}
+
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[topic][ch]; ok {
+			delete(b.subscribers[topic], ch)
+			close(ch)
+		}
+	}
+	return replay, ch, complete, cancel
+}
+
+// PublishOrder notifies both the student and the stand of an order change
+func PublishOrder(eventType string, order models.Order) {
+	data := map[string]interface{}{
+		"order_id":       order.ID,
+		"order_number":   order.OrderNumber,
+		"status":         order.Status,
+		"user_id":        order.UserID,
+		"stand_id":       order.StandID,
+		"total_amount":   order.TotalAmount,
+		"payment_method": order.PaymentMethod,
+	}
+	Default.Publish(UserTopic(order.UserID), eventType, data)
+	Default.Publish(StandTopic(order.StandID), eventType, data)
+}
+
+// ServeStream streams the topic as Server-Sent Events until the client
+// disconnects. It honours the Last-Event-ID header (or last_event_id query)
+// to replay missed events.
+func ServeStream(w http.ResponseWriter, r *http.Request, bus *Bus, topic string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	lastEventID, _ := strconv.ParseUint(lastID, 10, 64)
+
+	replay, stream, complete, cancel := bus.Subscribe(topic, lastEventID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	if !complete {
+		// Tell the client to refetch its state, the gap can't be replayed
+		writeEvent(w, Event{ID: lastEventID, Type: Resync, CreatedAt: time.Now()})
+	}
+	for _, event := range replay {
+		writeEvent(w, event)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+			writeEvent(w, event)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes a single SSE frame
+func writeEvent(w http.ResponseWriter, event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
@@ -0,0 +1,267 @@
+package fulfilment
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"swipeup-admin-v2/internal/app/inventory"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/notify"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/options"
+	"swipeup-admin-v2/internal/app/orderstatus"
+	"swipeup-admin-v2/internal/app/wallet"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Item fulfilment statuses; an unchanged item has an empty status
+const (
+	Unavailable          = "unavailable"
+	PartiallyUnavailable = "partially_unavailable"
+	Substituted          = "substituted"
+)
+
+// Refund statuses of cash and QRIS orders
+const (
+	RefundPending  = orderstatus.RefundPending
+	RefundRefunded = orderstatus.RefundRefunded
+)
+
+var (
+	// ErrOrderNotChangeable is returned when the order is past cooking or closed
+	ErrOrderNotChangeable = errors.New("order items can only be changed before the order is ready")
+	// ErrItemNotFound is returned for an item that isn't part of the order
+	ErrItemNotFound = errors.New("order item not found")
+	// ErrAlreadyChanged is returned when the item was marked before
+	ErrAlreadyChanged = errors.New("order item was already changed")
+	// ErrInvalidChange is returned for an unknown action or quantity
+	ErrInvalidChange = errors.New("invalid item change")
+)
+
+// Change is a stand's change to one order item
+type Change struct {
+	ItemID              uint
+	Action              string // unavailable or substituted
+	Quantity            int    // Units that can't be served, all when 0 (unavailable only)
+	SubstituteProductID uint   // Product served instead (substituted only)
+	Note                string
+}
+
+// Result describes what a change did to the order
+type Result struct {
+	Item          models.OrderItem `json:"item"`
+	Difference    float64          `json:"difference"`     // Amount taken off the order total
+	Refunded      float64          `json:"refunded"`       // Credited back to the wallet
+	PendingRefund float64          `json:"pending_refund"` // To be paid back in cash by the stand
+	Cancelled     bool             `json:"cancelled"`      // Nothing was left, so the order was cancelled
+}
+
+// changeable are the statuses in which a stand can still change items
+var changeable = map[string]bool{
+	orderstatus.PaymentPending: true,
+	orderstatus.Request:        true,
+	orderstatus.Cooking:        true,
+}
+
+// Apply marks an order item unavailable (all or some units) or substituted,
+// lowers the order total and refunds the difference: to the wallet for what
+// was paid from it, otherwise flagged as a pending cash refund. Substitutes
+// never cost the student more than the original item. If no item is left the
+// order is cancelled. The student is notified. It must be called inside a
+// database transaction; the caller publishes the order event after commit.
+func Apply(tx *gorm.DB, order *models.Order, change Change, actor orderstatus.Actor) (*Result, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(order, order.ID).Error; err != nil {
+		return nil, err
+	}
+	if !changeable[order.Status] {
+		return nil, ErrOrderNotChangeable
+	}
+
+	var item models.OrderItem
+	if err := tx.Where("id = ? AND order_id = ?", change.ItemID, order.ID).Preload("Product").First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+	if item.FulfilmentStatus != "" {
+		return nil, ErrAlreadyChanged
+	}
+
+	item.OriginalSubtotal = item.Subtotal
+	item.FulfilmentNote = change.Note
+
+	switch change.Action {
+	case Unavailable:
+		quantity := change.Quantity
+		if quantity == 0 {
+			quantity = item.Quantity
+		}
+		if quantity < 1 || quantity > item.Quantity {
+			return nil, fmt.Errorf("%w: quantity must be between 1 and %d", ErrInvalidChange, item.Quantity)
+		}
+		item.UnavailableQuantity = quantity
+		item.FulfilmentStatus = Unavailable
+		if quantity < item.Quantity {
+			item.FulfilmentStatus = PartiallyUnavailable
+		}
+		item.Subtotal = item.Price * float64(item.Quantity-quantity)
+
+	case Substituted:
+		if change.SubstituteProductID == 0 || change.SubstituteProductID == item.ProductID {
+			return nil, fmt.Errorf("%w: choose a different substitute product", ErrInvalidChange)
+		}
+		products, err := inventory.Reserve(tx, []inventory.Line{{ProductID: change.SubstituteProductID, Quantity: item.Quantity}}, order.StandID)
+		if err != nil {
+			return nil, err
+		}
+		substitute := products[change.SubstituteProductID]
+		// The original product ran out, so its stock isn't returned
+		price := math.Min(options.UnitPrice(substitute, 0), item.Price)
+		item.SubstituteProductID = &substitute.ID
+		item.SubstituteProduct = &substitute
+		item.FulfilmentStatus = Substituted
+		item.Subtotal = price * float64(item.Quantity)
+
+	default:
+		return nil, fmt.Errorf("%w: action must be unavailable or substituted", ErrInvalidChange)
+	}
+
+	if err := tx.Omit(clause.Associations).Save(&item).Error; err != nil {
+		return nil, err
+	}
+
+	// Nothing left to serve: cancel the order instead, which refunds the
+	// payment and returns the stock of the remaining items
+	var remaining int64
+	if err := tx.Model(&models.OrderItem{}).
+		Where("order_id = ? AND (fulfilment_status IS NULL OR fulfilment_status <> ?)", order.ID, Unavailable).
+		Count(&remaining).Error; err != nil {
+		return nil, err
+	}
+	if remaining == 0 {
+		result := &Result{Item: item, Difference: order.TotalAmount, Cancelled: true}
+		if err := refund(tx, order, result, false); err != nil {
+			return nil, err
+		}
+		if err := orderstatus.Transition(tx, order, orderstatus.Cancelled, actor, "All items unavailable"); err != nil {
+			return nil, err
+		}
+		return result, notifyChange(tx, order, item, result)
+	}
+
+	result := &Result{Item: item, Difference: item.OriginalSubtotal - item.Subtotal}
+	if err := tx.Model(order).Update("total_amount", gorm.Expr("total_amount - ?", result.Difference)).Error; err != nil {
+		return nil, err
+	}
+	order.TotalAmount -= result.Difference
+
+	if err := refund(tx, order, result, true); err != nil {
+		return nil, err
+	}
+	return result, notifyChange(tx, order, item, result)
+}
+
+// MarkRefunded records that the stand paid a pending cash refund back
+func MarkRefunded(tx *gorm.DB, order *models.Order) error {
+	if order.RefundStatus != RefundPending {
+		return fmt.Errorf("%w: no pending refund", ErrInvalidChange)
+	}
+	now := time.Now()
+	if err := tx.Model(order).Updates(map[string]interface{}{
+		"refund_status": RefundRefunded,
+		"refunded_at":   now,
+	}).Error; err != nil {
+		return err
+	}
+	order.RefundStatus = RefundRefunded
+	order.RefundedAt = &now
+	return nil
+}
+
+// refund pays the difference back: to the wallet up to what was paid from it,
+// the rest is flagged for a cash refund. Unpaid QRIS orders just cost less.
+// With credit false the refund is only reported, for a cancellation that
+// pays it back.
+func refund(tx *gorm.DB, order *models.Order, result *Result, credit bool) error {
+	if result.Difference <= 0 || order.Status == orderstatus.PaymentPending {
+		return nil
+	}
+
+	outstanding, err := orderstatus.WalletOutstanding(tx, order)
+	if err != nil {
+		return err
+	}
+	fromWallet := math.Min(result.Difference, math.Max(outstanding, 0))
+	if fromWallet > 0 && credit {
+		transactionNumber, err := numbering.Next(tx, numbering.Refund, order.StandID)
+		if err != nil {
+			return err
+		}
+		if _, err := wallet.Credit(tx, wallet.Entry{
+			UserID:            order.UserID,
+			TransactionNumber: transactionNumber,
+			Type:              "refund",
+			Amount:            fromWallet,
+			Description:       "Refund: " + order.OrderNumber + " item changed",
+			OrderID:           &order.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	result.Refunded = fromWallet
+
+	// Paid in cash or QRIS: the stand pays this back at the counter
+	if cash := result.Difference - fromWallet; cash > 0 && order.PaymentMethod != "card" {
+		if credit {
+			if err := orderstatus.FlagCashRefund(tx, order, cash); err != nil {
+				return err
+			}
+		}
+		result.PendingRefund = cash
+	}
+	return nil
+}
+
+// notifyChange tells the student what changed in their order
+func notifyChange(tx *gorm.DB, order *models.Order, item models.OrderItem, result *Result) error {
+	var message string
+	switch item.FulfilmentStatus {
+	case Substituted:
+		message = fmt.Sprintf("%s in order %s was replaced with %s.", item.Product.Name, order.OrderNumber, item.SubstituteProduct.Name)
+	case PartiallyUnavailable:
+		message = fmt.Sprintf("%d of %d %s in order %s are no longer available.", item.UnavailableQuantity, item.Quantity, item.Product.Name, order.OrderNumber)
+	default:
+		message = fmt.Sprintf("%s in order %s is no longer available.", item.Product.Name, order.OrderNumber)
+	}
+	switch {
+	case result.Cancelled:
+		message += " Nothing else was left, so the order was cancelled."
+	case result.Refunded > 0 && result.PendingRefund > 0:
+		message += fmt.Sprintf(" Rp %.0f was refunded to your balance and Rp %.0f will be paid back at the stand.", result.Refunded, result.PendingRefund)
+	case result.Refunded > 0:
+		message += fmt.Sprintf(" Rp %.0f was refunded to your balance.", result.Refunded)
+	case result.PendingRefund > 0:
+		message += fmt.Sprintf(" Collect Rp %.0f at the stand.", result.PendingRefund)
+	}
+
+	return notify.Queue(tx, notify.Preference(tx, order.UserID), notify.Message{
+		UserID:  order.UserID,
+		Type:    notify.OrderChanged,
+		Title:   "Your order was changed",
+		Message: message,
+		Data: map[string]interface{}{
+			"order_id":       order.ID,
+			"order_number":   order.OrderNumber,
+			"order_item_id":  item.ID,
+			"status":         item.FulfilmentStatus,
+			"new_total":      order.TotalAmount,
+			"refunded":       result.Refunded,
+			"pending_refund": result.PendingRefund,
+		},
+	})
+}
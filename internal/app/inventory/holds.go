@@ -0,0 +1,93 @@
+package inventory
+
+import (
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/settings"
+
+	"gorm.io/gorm"
+)
+
+// DefaultHoldMinutes is used when the cart_hold_minutes setting is missing, so
+// holds stay off until a school turns them on
+const DefaultHoldMinutes = 0
+
+// HoldTTL returns how long cart items hold stock; zero means holds are disabled
+func HoldTTL(db *gorm.DB) time.Duration {
+	return time.Duration(settings.GetFloat(db, "cart_hold_minutes", DefaultHoldMinutes) * float64(time.Minute))
+}
+
+// Hold checks that quantity units of the product can be held for the cart and
+// returns the hold expiry. Holds don't change products.stock; they are counted
+// against it by Reserve, Hold and AvailableStock until they expire. The caller
+// stores quantity and the expiry on the cart item in the same transaction.
+// It returns a nil expiry when holds are disabled.
+func Hold(tx *gorm.DB, cartID, productID uint, quantity int) (*time.Time, error) {
+	ttl := HoldTTL(tx)
+	if ttl <= 0 {
+		return nil, nil
+	}
+
+	if _, err := lockProduct(tx, Line{ProductID: productID, Quantity: quantity}, 0, cartID); err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	return &expiresAt, nil
+}
+
+// AvailableStock returns stock minus active cart holds for the given products.
+// The viewer's own cart holds are not subtracted, since that stock is theirs
+// to check out.
+func AvailableStock(db *gorm.DB, products []models.Product, viewerID uint) (map[uint]int, error) {
+	available := make(map[uint]int, len(products))
+	if len(products) == 0 {
+		return available, nil
+	}
+
+	ids := make([]uint, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+		available[product.ID] = product.Stock
+	}
+
+	var rows []struct {
+		ProductID uint
+		Held      int
+	}
+	if err := db.Model(&models.CartItem{}).
+		Select("product_id, SUM(held_quantity) AS held").
+		Where("product_id IN ? AND held_quantity > 0 AND hold_expires_at > ?", ids, time.Now()).
+		Where("cart_id NOT IN (?)", db.Session(&gorm.Session{NewDB: true}).Model(&models.Cart{}).Select("id").Where("user_id = ?", viewerID)).
+		Group("product_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		available[row.ProductID] -= row.Held
+		if available[row.ProductID] < 0 {
+			available[row.ProductID] = 0
+		}
+	}
+	return available, nil
+}
+
+// ReleaseExpiredHolds clears holds that have passed their expiry
+func ReleaseExpiredHolds(db *gorm.DB) (int64, error) {
+	result := db.Model(&models.CartItem{}).
+		Where("held_quantity > 0 AND hold_expires_at <= ?", time.Now()).
+		Updates(map[string]interface{}{"held_quantity": 0, "hold_expires_at": nil})
+	return result.RowsAffected, result.Error
+}
+
+// heldQuantity sums active holds on a product, excluding the given cart
+func heldQuantity(tx *gorm.DB, productID, excludeCartID uint) (int, error) {
+	var held int
+	err := tx.Model(&models.CartItem{}).
+		Select("COALESCE(SUM(held_quantity), 0)").
+		Where("product_id = ? AND cart_id <> ? AND held_quantity > 0 AND hold_expires_at > ?", productID, excludeCartID, time.Now()).
+		Scan(&held).Error
+	return held, err
+}
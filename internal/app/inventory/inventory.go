@@ -0,0 +1,171 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"swipeup-admin-v2/internal/app/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrProductNotFound is returned for an unknown product, or one of another stand
+	ErrProductNotFound = errors.New("product not found")
+	// ErrProductUnavailable is returned for an inactive product
+	ErrProductUnavailable = errors.New("product is not available")
+	// ErrInsufficientStock is returned when the stock can't cover the quantity
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrInvalidQuantity is returned for a quantity below one
+	ErrInvalidQuantity = errors.New("invalid quantity")
+)
+
+// Line is a product and quantity to reserve or release
+type Line struct {
+	ProductID uint
+	Quantity  int
+}
+
+// StockError describes which product a reservation failed on
+type StockError struct {
+	Err         error
+	ProductID   uint
+	ProductName string
+}
+
+// Error implements the error interface
+func (e *StockError) Error() string {
+	return fmt.Sprintf("%v: product %d", e.Err, e.ProductID)
+}
+
+// Unwrap returns the underlying sentinel error
+func (e *StockError) Unwrap() error {
+	return e.Err
+}
+
+// Message returns the error text shown to API clients
+func (e *StockError) Message() string {
+	switch e.Err {
+	case ErrProductNotFound:
+		return fmt.Sprintf("Product not found: %d", e.ProductID)
+	case ErrProductUnavailable:
+		return "Product is not available: " + e.ProductName
+	case ErrInvalidQuantity:
+		return fmt.Sprintf("Invalid quantity for product: %d", e.ProductID)
+	default:
+		return "Insufficient stock for product: " + e.ProductName
+	}
+}
+
+// Reserve takes the quantities out of stock and returns the products by ID.
+// Each product row is locked (SELECT ... FOR UPDATE) in ID order, checked
+// against its stock minus other carts' active holds and then decremented with
+// a conditional UPDATE, so concurrent reservations can never oversell.
+// Pass standID 0 to allow products of any stand. It must be called inside a
+// database transaction; on error the caller rolls back.
+func Reserve(tx *gorm.DB, lines []Line, standID uint) (map[uint]models.Product, error) {
+	return ReserveForCart(tx, lines, standID, 0)
+}
+
+// ReserveForCart is Reserve for a cart checkout: the cart's own holds count
+// as available to it.
+func ReserveForCart(tx *gorm.DB, lines []Line, standID, cartID uint) (map[uint]models.Product, error) {
+	merged, err := merge(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uint]models.Product, len(merged))
+	for _, line := range merged {
+		product, err := lockProduct(tx, line, standID, cartID)
+		if err != nil {
+			return nil, err
+		}
+
+		update := tx.Model(&models.Product{}).
+			Where("id = ? AND stock >= ?", line.ProductID, line.Quantity).
+			Update("stock", gorm.Expr("stock - ?", line.Quantity))
+		if update.Error != nil {
+			return nil, update.Error
+		}
+		if update.RowsAffected == 0 {
+			return nil, &StockError{Err: ErrInsufficientStock, ProductID: product.ID, ProductName: product.Name}
+		}
+
+		product.Stock -= line.Quantity
+		result[product.ID] = *product
+	}
+	return result, nil
+}
+
+// Release puts the quantities back into stock, e.g. when an order is cancelled.
+// It must be called inside a database transaction.
+func Release(tx *gorm.DB, lines []Line) error {
+	positive := make([]Line, 0, len(lines))
+	for _, line := range lines {
+		if line.Quantity > 0 {
+			positive = append(positive, line)
+		}
+	}
+	merged, err := merge(positive)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range merged {
+		// Unscoped so stock of a product deleted since the order still adds up
+		if err := tx.Unscoped().Model(&models.Product{}).Where("id = ?", line.ProductID).
+			Update("stock", gorm.Expr("stock + ?", line.Quantity)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge sums duplicate products and sorts the lines by product ID
+func merge(lines []Line) ([]Line, error) {
+	quantities := make(map[uint]int)
+	for _, line := range lines {
+		if line.Quantity < 1 {
+			return nil, &StockError{Err: ErrInvalidQuantity, ProductID: line.ProductID}
+		}
+		quantities[line.ProductID] += line.Quantity
+	}
+
+	merged := make([]Line, 0, len(quantities))
+	for productID, quantity := range quantities {
+		merged = append(merged, Line{ProductID: productID, Quantity: quantity})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].ProductID < merged[j].ProductID })
+	return merged, nil
+}
+
+// lockProduct locks a product row and checks it can supply the line
+func lockProduct(tx *gorm.DB, line Line, standID, cartID uint) (*models.Product, error) {
+	var product models.Product
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", line.ProductID)
+	if standID != 0 {
+		query = query.Where("stand_id = ?", standID)
+	}
+	if err := query.First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &StockError{Err: ErrProductNotFound, ProductID: line.ProductID}
+		}
+		return nil, err
+	}
+
+	if !product.IsActive {
+		return nil, &StockError{Err: ErrProductUnavailable, ProductID: product.ID, ProductName: product.Name}
+	}
+
+	held, err := heldQuantity(tx, product.ID, cartID)
+	if err != nil {
+		return nil, err
+	}
+	if product.Stock-held < line.Quantity {
+		return nil, &StockError{Err: ErrInsufficientStock, ProductID: product.ID, ProductName: product.Name}
+	}
+	return &product, nil
+}
@@ -0,0 +1,263 @@
+package inventory_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"swipeup-admin-v2/internal/api/siswa"
+	"swipeup-admin-v2/internal/api/stand"
+	"swipeup-admin-v2/internal/app/inventory"
+	"swipeup-admin-v2/internal/app/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// rounds is how many times the three order paths race for the last unit
+const rounds = 10
+
+// TestLastUnitSoldOnce runs a cart checkout, a student order and a stand
+// order at the same time against a product with one unit left and checks
+// that exactly one of them gets it. SQLite runs the transactions one after
+// another (see openDB), so this only proves the three paths share the stock
+// and roll back cleanly; the tests below cover the checks inside Reserve.
+func TestLastUnitSoldOnce(t *testing.T) {
+	db := openDB(t)
+
+	owner := models.User{Name: "Stand", Email: "stand@example.com", Role: "stand_admin", Password: "x", IsActive: true}
+	student := models.User{Name: "Student", Email: "student@example.com", Role: "student", Password: "x", IsActive: true, Balance: 1000000}
+	for _, user := range []*models.User{&owner, &student} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	category := models.Category{Name: "Food"}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	product := models.Product{Name: "Last one", CategoryID: category.ID, Price: 10000, Stock: 1, IsActive: true, StandID: owner.ID}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	cart := models.Cart{UserID: student.ID}
+	if err := db.Create(&cart).Error; err != nil {
+		t.Fatalf("create cart: %v", err)
+	}
+	// Without holds the cart doesn't reserve the unit before checkout
+	if err := db.Create(&models.GlobalSettings{Key: "cart_hold_minutes", Value: "0", IsActive: true}).Error; err != nil {
+		t.Fatalf("create setting: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/siswa/cart/checkout", as(student.ID), siswa.NewCartHandler(db).Checkout)
+	router.POST("/siswa/orders", as(student.ID), siswa.NewOrderHandler(db).CreateOrder)
+	router.POST("/stand/orders", as(owner.ID), stand.NewOrderHandler(db).CreateOrder)
+
+	items := []gin.H{{"product_id": product.ID, "quantity": 1}}
+	requests := map[string]gin.H{
+		"/siswa/cart/checkout": {"payment_method": "card"},
+		"/siswa/orders":        {"payment_method": "card", "items": items},
+		"/stand/orders":        {"user_id": student.ID, "payment_method": "cash", "items": items},
+	}
+
+	for round := 1; round <= rounds; round++ {
+		if err := db.Model(&models.Product{}).Where("id = ?", product.ID).Update("stock", 1).Error; err != nil {
+			t.Fatalf("reset stock: %v", err)
+		}
+		cartItem := models.CartItem{CartID: cart.ID, ProductID: product.ID, Quantity: 1, Price: product.Price, Subtotal: product.Price, StandID: owner.ID}
+		if err := db.Create(&cartItem).Error; err != nil {
+			t.Fatalf("create cart item: %v", err)
+		}
+		var ordersBefore int64
+		db.Model(&models.Order{}).Count(&ordersBefore)
+
+		var (
+			wg    sync.WaitGroup
+			mu    sync.Mutex
+			codes = make(map[string]int)
+			start = make(chan struct{})
+		)
+		for path, body := range requests {
+			payload, _ := json.Marshal(body)
+			wg.Add(1)
+			go func(path string, payload []byte) {
+				defer wg.Done()
+				<-start
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload)))
+				mu.Lock()
+				codes[path] = recorder.Code
+				mu.Unlock()
+			}(path, payload)
+		}
+		close(start)
+		wg.Wait()
+
+		created := 0
+		for path, code := range codes {
+			switch code {
+			case http.StatusCreated:
+				created++
+			case http.StatusBadRequest:
+			default:
+				t.Errorf("round %d: %s returned %d", round, path, code)
+			}
+		}
+		if created != 1 {
+			t.Errorf("round %d: %d orders got the last unit, want 1 (%v)", round, created, codes)
+		}
+
+		var final models.Product
+		if err := db.First(&final, product.ID).Error; err != nil {
+			t.Fatalf("reload product: %v", err)
+		}
+		if final.Stock != 0 {
+			t.Errorf("round %d: stock is %d, want 0", round, final.Stock)
+		}
+		var ordersAfter int64
+		db.Model(&models.Order{}).Count(&ordersAfter)
+		if ordersAfter-ordersBefore != 1 {
+			t.Errorf("round %d: %d orders created, want 1", round, ordersAfter-ordersBefore)
+		}
+
+		db.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{})
+	}
+}
+
+// TestReserveTwiceInOneTransaction takes the last unit and checks that a second
+// reservation in the same transaction is refused and leaves the stock at zero
+func TestReserveTwiceInOneTransaction(t *testing.T) {
+	db := openDB(t)
+	product := createProduct(t, db, 1)
+
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	lines := []inventory.Line{{ProductID: product.ID, Quantity: 1}}
+	if _, err := inventory.Reserve(tx, lines, 0); err != nil {
+		t.Fatalf("first reserve: %v", err)
+	}
+	_, err := inventory.Reserve(tx, lines, 0)
+	assertInsufficientStock(t, err)
+	assertStock(t, tx, product.ID, 0)
+}
+
+// TestConditionalDecrement empties the stock between the row lock and the
+// decrement, as a writer that skips the lock would, and checks that the
+// conditional UPDATE refuses to take the stock below zero
+func TestConditionalDecrement(t *testing.T) {
+	db := openDB(t)
+	product := createProduct(t, db, 1)
+
+	steal := true
+	if err := db.Callback().Query().After("gorm:query").Register("test:steal_stock", func(d *gorm.DB) {
+		if _, locked := d.Statement.Clauses["FOR"]; !locked || d.Statement.Table != "products" || !steal {
+			return
+		}
+		steal = false
+		if err := d.Session(&gorm.Session{NewDB: true}).Exec("UPDATE products SET stock = 0 WHERE id = ?", product.ID).Error; err != nil {
+			d.AddError(err)
+		}
+	}); err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	_, err := inventory.Reserve(tx, []inventory.Line{{ProductID: product.ID, Quantity: 1}}, 0)
+	if steal {
+		t.Fatal("the locked product read was not intercepted")
+	}
+	assertInsufficientStock(t, err)
+	assertStock(t, tx, product.ID, 0)
+}
+
+// createProduct creates an active product of a new stand with the given stock
+func createProduct(t *testing.T, db *gorm.DB, stock int) models.Product {
+	t.Helper()
+	owner := models.User{Name: "Stand", Email: "stand@example.com", Role: "stand_admin", Password: "x", IsActive: true}
+	if err := db.Create(&owner).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	category := models.Category{Name: "Food"}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+	product := models.Product{Name: "Last one", CategoryID: category.ID, Price: 10000, Stock: stock, IsActive: true, StandID: owner.ID}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	return product
+}
+
+func assertInsufficientStock(t *testing.T, err error) {
+	t.Helper()
+	var stockErr *inventory.StockError
+	if !errors.As(err, &stockErr) || !errors.Is(err, inventory.ErrInsufficientStock) {
+		t.Fatalf("got error %v, want an insufficient stock StockError", err)
+	}
+}
+
+func assertStock(t *testing.T, db *gorm.DB, productID uint, want int) {
+	t.Helper()
+	var product models.Product
+	if err := db.First(&product, productID).Error; err != nil {
+		t.Fatalf("reload product: %v", err)
+	}
+	if product.Stock != want {
+		t.Errorf("stock is %d, want %d", product.Stock, want)
+	}
+}
+
+// openDB migrates a fresh SQLite database. Transactions take the write lock
+// when they begin, so concurrent orders queue up the way row locks make them
+// queue on MySQL.
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Category{},
+		&models.Product{},
+		&models.ProductOptionGroup{},
+		&models.ProductOption{},
+		&models.Checkout{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.OrderItemOption{},
+		&models.Transaction{},
+		&models.StandSettings{},
+		&models.GlobalSettings{},
+		&models.Cart{},
+		&models.CartItem{},
+		&models.CartItemOption{},
+		&models.DocumentSequence{},
+		&models.OrderStatusHistory{},
+		&models.PickupSlot{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// as authenticates every request as the given user
+func as(userID uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Next()
+	}
+}
@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"swipeup-admin-v2/internal/app/auth"
+	"swipeup-admin-v2/internal/app/events"
+	"swipeup-admin-v2/internal/app/inventory"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/notify"
+	"swipeup-admin-v2/internal/app/orderstatus"
+	"swipeup-admin-v2/internal/app/settings"
+
+	"gorm.io/gorm"
+)
+
+// DefaultPaymentTimeoutMinutes is used when the qris_payment_timeout_minutes setting is missing
+const DefaultPaymentTimeoutMinutes = 30
+
+// Default registers the server's built-in jobs on a new scheduler
+func Default(db *gorm.DB) *Scheduler {
+	s := NewScheduler()
+
+	s.Register(Job{
+		Name:     "expire_unpaid_orders",
+		Interval: time.Minute,
+		Run:      func(ctx context.Context) error { return ExpireUnpaidOrders(db) },
+	})
+
+	dispatcher := notify.NewDispatcher(db, notify.LogChannel{}, notify.NewWebhookChannel(db))
+	s.Register(Job{
+		Name:     "dispatch_notifications",
+		Interval: 30 * time.Second,
+		Run:      dispatcher.DispatchPending,
+	})
+
+	s.Register(Job{
+		Name:     "sweep_expired_tokens",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			auth.TokenStoreInstance.CleanupExpiredTokens()
+			return nil
+		},
+	})
+
+	s.Register(Job{
+		Name:     "sweep_idempotency_keys",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			return db.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{}).Error
+		},
+	})
+
+	s.Register(Job{
+		Name:     "release_cart_holds",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := inventory.ReleaseExpiredHolds(db)
+			return err
+		},
+	})
+
+	return s
+}
+
+// ExpireUnpaidOrders cancels QRIS orders that stayed in payment_pending longer
+// than the qris_payment_timeout_minutes setting. Cancelling returns the stock;
+// the student is notified.
+func ExpireUnpaidOrders(db *gorm.DB) error {
+	timeout := settings.GetFloat(db, "qris_payment_timeout_minutes", DefaultPaymentTimeoutMinutes)
+	if timeout <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-time.Duration(timeout) * time.Minute)
+
+	var orders []models.Order
+	if err := db.Where("status = ? AND payment_method = ? AND created_at < ?", orderstatus.PaymentPending, "qris", cutoff).
+		Order("created_at ASC").Limit(100).Find(&orders).Error; err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		if err := expireOrder(db, &order, timeout); err != nil {
+			log.Printf("jobs: failed to expire order %s: %v", order.OrderNumber, err)
+			continue
+		}
+		events.PublishOrder(events.OrderCancelled, order)
+	}
+	return nil
+}
+
+// expireOrder cancels one unpaid order and queues the student notification
+func expireOrder(db *gorm.DB, order *models.Order, timeout float64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		note := fmt.Sprintf("Payment not received within %.0f minutes", timeout)
+		if err := orderstatus.Transition(tx, order, orderstatus.Cancelled, orderstatus.Actor{Role: orderstatus.RoleSystem}, note); err != nil {
+			return err
+		}
+
+		return notify.Queue(tx, notify.Preference(tx, order.UserID), notify.Message{
+			UserID:  order.UserID,
+			Type:    notify.OrderExpired,
+			Title:   "Order cancelled",
+			Message: fmt.Sprintf("Your order %s was cancelled because no QRIS payment proof was uploaded within %.0f minutes.", order.OrderNumber, timeout),
+			Data: map[string]interface{}{
+				"order_id":     order.ID,
+				"order_number": order.OrderNumber,
+				"stand_id":     order.StandID,
+			},
+		})
+	})
+}
@@ -0,0 +1,207 @@
+// Package kitchen builds the kitchen display of a stand: the quantities to
+// prepare across all open orders and how long each order has been waiting.
+package kitchen
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"swipeup-admin-v2/internal/app/fulfilment"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/options"
+	"swipeup-admin-v2/internal/app/orderstatus"
+
+	"gorm.io/gorm"
+)
+
+// Statuses are the order statuses shown on the kitchen display
+var Statuses = []string{orderstatus.Request, orderstatus.Cooking}
+
+// next is the status a bump moves an order to
+var next = map[string]string{
+	orderstatus.Request: orderstatus.Cooking,
+	orderstatus.Cooking: orderstatus.Ready,
+}
+
+// Next returns the status an order moves to when the kitchen bumps it
+func Next(status string) (string, bool) {
+	to, ok := next[status]
+	return to, ok
+}
+
+// Note is an item note the cook has to see
+type Note struct {
+	OrderID     uint   `json:"order_id"`
+	OrderNumber string `json:"order_number"`
+	QueueNumber string `json:"queue_number,omitempty"`
+	Quantity    int    `json:"quantity"`
+	Note        string `json:"note"`
+}
+
+// Item is one product with one set of options, summed over the open orders
+type Item struct {
+	Key            string `json:"key"` // product_id:option_ids
+	ProductID      uint   `json:"product_id"`
+	ProductName    string `json:"product_name"`
+	Options        string `json:"options,omitempty"`
+	Quantity       int    `json:"quantity"`
+	ToStart        int    `json:"to_start"` // In orders still in request
+	Cooking        int    `json:"cooking"`
+	OrderIDs       []uint `json:"order_ids"`
+	Notes          []Note `json:"notes,omitempty"`
+	WaitingSeconds int64  `json:"waiting_seconds"` // Of the oldest order containing it
+}
+
+// Order is an open order with its waiting time
+type Order struct {
+	ID             uint       `json:"id"`
+	OrderNumber    string     `json:"order_number"`
+	QueueNumber    string     `json:"queue_number,omitempty"`
+	Status         string     `json:"status"`
+	NextStatus     string     `json:"next_status"`
+	CustomerName   string     `json:"customer_name"`
+	ItemCount      int        `json:"item_count"`
+	ScheduledFor   *time.Time `json:"scheduled_for,omitempty"`
+	WaitingSince   time.Time  `json:"waiting_since"` // When the order entered request
+	WaitingSeconds int64      `json:"waiting_seconds"`
+}
+
+// Board is the kitchen display
+type Board struct {
+	Items       []Item    `json:"items"`
+	Orders      []Order   `json:"orders"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// WaitingSince returns when each order first entered request, taken from
+// the status history
+func WaitingSince(db *gorm.DB, orderIDs []uint) (map[uint]time.Time, error) {
+	since := make(map[uint]time.Time, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return since, nil
+	}
+
+	var rows []struct {
+		OrderID uint
+		Since   time.Time
+	}
+	if err := db.Model(&models.OrderStatusHistory{}).
+		Select("order_id, MIN(created_at) AS since").
+		Where("order_id IN ? AND to_status = ?", orderIDs, orderstatus.Request).
+		Group("order_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		since[row.OrderID] = row.Since
+	}
+	return since, nil
+}
+
+// Build sums the items of request and cooking orders by product and options.
+// Orders must be loaded with their user, items, item options and substitute
+// products; orders in other statuses are ignored. since comes from
+// WaitingSince, falling back to the order creation time.
+func Build(orders []models.Order, since map[uint]time.Time, now time.Time) *Board {
+	board := &Board{Items: []Item{}, Orders: []Order{}, GeneratedAt: now}
+	items := make(map[string]*Item)
+	var keys []string
+
+	for _, order := range orders {
+		nextStatus, ok := Next(order.Status)
+		if !ok {
+			continue
+		}
+
+		waitingSince, ok := since[order.ID]
+		if !ok {
+			waitingSince = order.CreatedAt
+		}
+		waiting := int64(now.Sub(waitingSince).Seconds())
+		if waiting < 0 {
+			waiting = 0
+		}
+
+		entry := Order{
+			ID:             order.ID,
+			OrderNumber:    order.OrderNumber,
+			QueueNumber:    order.QueueNumber,
+			Status:         order.Status,
+			NextStatus:     nextStatus,
+			CustomerName:   order.User.Name,
+			ScheduledFor:   order.ScheduledFor,
+			WaitingSince:   waitingSince,
+			WaitingSeconds: waiting,
+		}
+
+		for _, orderItem := range order.OrderItems {
+			quantity := orderItem.Quantity - orderItem.UnavailableQuantity
+			if orderItem.FulfilmentStatus == fulfilment.Unavailable || quantity <= 0 {
+				continue
+			}
+			entry.ItemCount += quantity
+
+			// A substituted item is prepared as the substitute product
+			product := orderItem.Product
+			if orderItem.FulfilmentStatus == fulfilment.Substituted && orderItem.SubstituteProduct != nil {
+				product = *orderItem.SubstituteProduct
+			}
+
+			key := fmt.Sprintf("%d:%s", product.ID, options.KeyOf(options.OrderOptionIDs(orderItem.Options)))
+			item, ok := items[key]
+			if !ok {
+				item = &Item{
+					Key:         key,
+					ProductID:   product.ID,
+					ProductName: product.Name,
+					Options:     options.Describe(orderItem.Options),
+					OrderIDs:    []uint{},
+				}
+				items[key] = item
+				keys = append(keys, key)
+			}
+
+			item.Quantity += quantity
+			if order.Status == orderstatus.Request {
+				item.ToStart += quantity
+			} else {
+				item.Cooking += quantity
+			}
+			if len(item.OrderIDs) == 0 || item.OrderIDs[len(item.OrderIDs)-1] != order.ID {
+				item.OrderIDs = append(item.OrderIDs, order.ID)
+			}
+			if orderItem.Note != "" {
+				item.Notes = append(item.Notes, Note{
+					OrderID:     order.ID,
+					OrderNumber: order.OrderNumber,
+					QueueNumber: order.QueueNumber,
+					Quantity:    quantity,
+					Note:        orderItem.Note,
+				})
+			}
+			if waiting > item.WaitingSeconds {
+				item.WaitingSeconds = waiting
+			}
+		}
+
+		board.Orders = append(board.Orders, entry)
+	}
+
+	for _, key := range keys {
+		board.Items = append(board.Items, *items[key])
+	}
+
+	// Largest batches first, then the items waiting longest
+	sort.SliceStable(board.Items, func(i, j int) bool {
+		if board.Items[i].Quantity != board.Items[j].Quantity {
+			return board.Items[i].Quantity > board.Items[j].Quantity
+		}
+		return board.Items[i].WaitingSeconds > board.Items[j].WaitingSeconds
+	})
+	sort.SliceStable(board.Orders, func(i, j int) bool {
+		return board.Orders[i].WaitingSince.Before(board.Orders[j].WaitingSince)
+	})
+
+	return board
+}
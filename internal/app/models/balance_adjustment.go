@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BalanceAdjustment represents an admin correction to a user's balance
+type BalanceAdjustment struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Adjustment information
+	UserID     uint    `json:"user_id" gorm:"not null;index"`
+	User       User    `json:"user" gorm:"foreignKey:UserID"`
+	Amount     float64 `json:"amount" gorm:"not null"`              // Signed: positive credits, negative debits
+	ReasonCode string  `json:"reason_code" gorm:"not null;size:30"` // correction, lost_card, manual_refund, cash_deposit, promotion, other
+	Comment    string  `json:"comment" gorm:"not null;size:255"`
+	Status     string  `json:"status" gorm:"not null;size:20;index"` // pending_approval, applied, rejected
+
+	// Approval
+	RequestedByID uint         `json:"requested_by_id" gorm:"not null"`
+	RequestedBy   User         `json:"requested_by" gorm:"foreignKey:RequestedByID"`
+	ReviewedByID  *uint        `json:"reviewed_by_id"`
+	ReviewedBy    *User        `json:"reviewed_by,omitempty" gorm:"foreignKey:ReviewedByID"`
+	ReviewedAt    *time.Time   `json:"reviewed_at"`
+	ReviewNote    string       `json:"review_note" gorm:"size:255"`
+	TransactionID *uint        `json:"transaction_id"` // Ledger entry created when applied
+	Transaction   *Transaction `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
+}
+
+// TableName specifies the table name for BalanceAdjustment model
+func (BalanceAdjustment) TableName() string {
+	return "balance_adjustments"
+}
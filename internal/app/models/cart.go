@@ -37,6 +37,15 @@ type CartItem struct {
 	Price     float64 `json:"price" gorm:"not null"`     // Price at time of adding to cart
 	Subtotal  float64 `json:"subtotal" gorm:"not null"`  // Quantity * Price
 	StandID   uint    `json:"stand_id" gorm:"not null"`  // For grouping by stand during checkout
+
+	// Options and note
+	Options   []CartItemOption `json:"options" gorm:"foreignKey:CartItemID"`
+	OptionKey string           `json:"-" gorm:"size:255;default:''"` // Sorted option IDs, items with the same product, options and note share a line
+	Note      string           `json:"note" gorm:"size:255;default:''"`
+
+	// Stock hold
+	HeldQuantity  int        `json:"held_quantity" gorm:"default:0"`        // Units held for this cart until the hold expires
+	HoldExpiresAt *time.Time `json:"hold_expires_at" gorm:"index"`
 }
 
 // TableName specifies the table name for Cart model
@@ -47,4 +56,24 @@ func (Cart) TableName() string {
 // TableName specifies the table name for CartItem model
 func (CartItem) TableName() string {
 	return "cart_items"
-}
\ No newline at end of file
+}
+// CartItemOption is an option chosen for a cart item, copied when it is added
+type CartItemOption struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Selected option
+	CartItemID uint    `json:"cart_item_id" gorm:"not null;index"`
+	GroupID    uint    `json:"group_id" gorm:"not null"`
+	OptionID   uint    `json:"option_id" gorm:"not null"`
+	GroupName  string  `json:"group_name" gorm:"size:100"`
+	OptionName string  `json:"option_name" gorm:"size:100"`
+	PriceDelta float64 `json:"price_delta" gorm:"default:0"`
+}
+
+// TableName specifies the table name for CartItemOption model
+func (CartItemOption) TableName() string {
+	return "cart_item_options"
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Checkout groups the orders created by one cart checkout, one order per stand
+type Checkout struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Checkout information
+	CheckoutNumber string  `json:"checkout_number" gorm:"uniqueIndex;not null;size:50"`
+	UserID         uint    `json:"user_id" gorm:"not null;index"`
+	User           User    `json:"user" gorm:"foreignKey:UserID"`
+	PaymentMethod  string  `json:"payment_method" gorm:"not null;size:20"` // card, cash, qris
+	TotalAmount    float64 `json:"total_amount" gorm:"not null"`           // Sum of the orders' totals
+	CashAmount     float64 `json:"cash_amount,omitempty" gorm:"default:0"` // Cash handed over for the whole checkout
+	Orders         []Order `json:"orders" gorm:"foreignKey:CheckoutID"`
+}
+
+// TableName specifies the table name for Checkout model
+func (Checkout) TableName() string {
+	return "checkouts"
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// DocumentSequence holds the last issued number per document type, scope and day
+type DocumentSequence struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Sequence information
+	DocType   string `json:"doc_type" gorm:"not null;size:10;uniqueIndex:idx_document_sequence"` // ORD, PUR, TOPUP, ...
+	Scope     string `json:"scope" gorm:"not null;size:20;uniqueIndex:idx_document_sequence"`    // Stand code (S03) or empty for school-wide
+	Day       string `json:"day" gorm:"not null;size:8;uniqueIndex:idx_document_sequence"`       // YYYYMMDD
+	LastValue int    `json:"last_value" gorm:"not null;default:0"`
+}
+
+// TableName specifies the table name for DocumentSequence model
+func (DocumentSequence) TableName() string {
+	return "document_sequences"
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FavoriteProduct is a product a student pinned to their favourites
+type FavoriteProduct struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Favourite information
+	UserID    uint    `json:"user_id" gorm:"not null;uniqueIndex:idx_favorite_user_product"`
+	ProductID uint    `json:"product_id" gorm:"not null;uniqueIndex:idx_favorite_user_product"`
+	Product   Product `json:"product" gorm:"foreignKey:ProductID"`
+}
+
+// TableName specifies the table name for FavoriteProduct model
+func (FavoriteProduct) TableName() string {
+	return "favorite_products"
+}
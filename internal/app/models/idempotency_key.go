@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// IdempotencyKey stores the outcome of a request made with an Idempotency-Key header
+type IdempotencyKey struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Key information
+	Key         string    `json:"key" gorm:"not null;size:100;uniqueIndex:idx_idempotency_user_key"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Method      string    `json:"method" gorm:"not null;size:10"`
+	Path        string    `json:"path" gorm:"not null;size:255"`
+	RequestHash string    `json:"request_hash" gorm:"not null;size:64"` // SHA-256 of method, path and body (form fields and file digests for multipart)
+	Status      string    `json:"status" gorm:"not null;size:20"`       // processing, completed
+	ExpiresAt   time.Time `json:"expires_at" gorm:"index"`
+
+	// Cached response
+	ResponseCode        int    `json:"response_code"`
+	ResponseContentType string `json:"response_content_type" gorm:"size:100"`
+	ResponseBody        string `json:"-" gorm:"type:mediumtext"`
+}
+
+// TableName specifies the table name for IdempotencyKey model
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
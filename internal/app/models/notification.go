@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification represents a message to a user, shown in the in-app inbox and
+// delivered to any external channels by the notification dispatcher
+type Notification struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Notification content
+	UserID  uint       `json:"user_id" gorm:"not null;index"`
+	Type    string     `json:"type" gorm:"not null;size:30;index"` // low_balance, large_purchase, order_ready, order_expired
+	Title   string     `json:"title" gorm:"not null;size:150"`
+	Message string     `json:"message" gorm:"type:text"`
+	Data    string     `json:"data" gorm:"type:text"` // JSON payload for clients and webhooks
+	InInbox bool       `json:"in_inbox" gorm:"default:true;index"`
+	ReadAt  *time.Time `json:"read_at"`
+
+	// External delivery (outbox)
+	Channels    string     `json:"-" gorm:"size:100"` // Comma separated external channels, e.g. webhook,log
+	Attempts    int        `json:"-" gorm:"default:0"`
+	LastError   string     `json:"-" gorm:"size:255"`
+	DeliveredAt *time.Time `json:"-" gorm:"index"`
+}
+
+// TableName specifies the table name for Notification model
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationPreference holds a user's notification thresholds and channels.
+// Nil thresholds fall back to the global settings.
+type NotificationPreference struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Preference information
+	UserID                 uint     `json:"user_id" gorm:"not null;uniqueIndex"`
+	LowBalanceThreshold    *float64 `json:"low_balance_threshold"`    // Notify when the balance drops below this amount, 0 disables
+	LargePurchaseThreshold *float64 `json:"large_purchase_threshold"` // Notify when a single purchase exceeds this amount, 0 disables
+	Channels               string   `json:"channels" gorm:"size:100"` // Comma separated: inbox, webhook, log
+}
+
+// TableName specifies the table name for NotificationPreference model
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
@@ -9,7 +9,7 @@ import (
 // Order represents a purchase order
 type Order struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	CreatedAt time.Time      `json:"created_at"`
+	CreatedAt time.Time      `json:"created_at" gorm:"index;index:idx_orders_stand_created,priority:2;index:idx_orders_status_created,priority:2"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
@@ -18,15 +18,33 @@ type Order struct {
 	UserID         uint       `json:"user_id" gorm:"not null;index"`
 	User           User       `json:"user" gorm:"foreignKey:UserID"`
 	TotalAmount    float64    `json:"total_amount" gorm:"not null"`
-	Status         string     `json:"status" gorm:"not null;size:20;default:'payment_pending'"` // payment_pending, request, cooking, done, cancelled
+	Status         string     `json:"status" gorm:"not null;size:20;default:'payment_pending';index:idx_orders_status_created,priority:1"` // payment_pending, request, cooking, ready, done, cancelled
 	PaymentMethod  string     `json:"payment_method" gorm:"size:20;default:'card'"`        // card, cash, qris
-	StandID        uint       `json:"stand_id" gorm:"not null;index"` // Canteen stand ID
+	StandID        uint       `json:"stand_id" gorm:"not null;index;index:idx_orders_stand_created,priority:1"` // Canteen stand ID
 	Stand          User       `json:"stand" gorm:"foreignKey:StandID"` // Reference to stand admin
 	OrderItems     []OrderItem `json:"order_items" gorm:"foreignKey:OrderID"`
+	CheckoutID     *uint      `json:"checkout_id,omitempty" gorm:"index"` // Cart checkout this order was split from
 
 	// Payment details
 	CashAmount     float64 `json:"cash_amount,omitempty" gorm:"default:0"`     // Amount of cash provided by user (for cash payment)
 	PaymentProofURL string `json:"payment_proof_url,omitempty" gorm:"type:text"` // URL to payment proof image (for QRIS payment)
+	PendingRefund   float64    `json:"pending_refund,omitempty" gorm:"default:0"`  // Owed back in cash after item changes or cancellation of a paid cash/QRIS order
+	RefundStatus    string     `json:"refund_status,omitempty" gorm:"size:20"`     // "", pending, refunded
+	RefundedAt      *time.Time `json:"refunded_at,omitempty"`
+
+	// Pickup
+	QueueNumber  string     `json:"queue_number,omitempty" gorm:"size:10;index"` // Daily stand queue number (A-001), assigned when the order enters request
+	PickupCode   string     `json:"pickup_code,omitempty" gorm:"size:16;index"`  // Shown to the student as a QR code
+	PickedUpAt   *time.Time `json:"picked_up_at,omitempty"`
+	PickupMethod string     `json:"pickup_method,omitempty" gorm:"size:10"` // qr, card, manual
+
+	// Pre-order
+	PickupSlotID *uint       `json:"pickup_slot_id,omitempty" gorm:"index"`
+	PickupSlot   *PickupSlot `json:"pickup_slot,omitempty" gorm:"foreignKey:PickupSlotID"`
+	ScheduledFor *time.Time  `json:"scheduled_for,omitempty" gorm:"index"` // Slot start on the pickup day, nil for immediate orders
+
+	// Settlement
+	SettlementID   *uint   `json:"settlement_id,omitempty" gorm:"index"` // Set once the order is included in a closed settlement
 }
 
 // TableName specifies the table name for Order model
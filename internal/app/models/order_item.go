@@ -21,9 +21,43 @@ type OrderItem struct {
 	Quantity  int     `json:"quantity" gorm:"not null"`
 	Price     float64 `json:"price" gorm:"not null"`
 	Subtotal  float64 `json:"subtotal" gorm:"not null"`
+
+	// Options and note
+	Options []OrderItemOption `json:"options" gorm:"foreignKey:OrderItemID"`
+	Note    string            `json:"note" gorm:"size:255"`
+
+	// Fulfilment changes made by the stand
+	FulfilmentStatus    string   `json:"fulfilment_status,omitempty" gorm:"size:25"` // "", unavailable, partially_unavailable, substituted
+	UnavailableQuantity int      `json:"unavailable_quantity,omitempty" gorm:"default:0"`
+	SubstituteProductID *uint    `json:"substitute_product_id,omitempty"`
+	SubstituteProduct   *Product `json:"substitute_product,omitempty" gorm:"foreignKey:SubstituteProductID"`
+	OriginalSubtotal    float64  `json:"original_subtotal,omitempty" gorm:"default:0"` // Subtotal before the change
+	FulfilmentNote      string   `json:"fulfilment_note,omitempty" gorm:"size:255"`
 }
 
 // TableName specifies the table name for OrderItem model
 func (OrderItem) TableName() string {
 	return "order_items"
 }
+
+// OrderItemOption is an option chosen for an order item. Names and price are
+// copied so later menu changes don't alter the order.
+type OrderItemOption struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Selected option
+	OrderItemID uint    `json:"order_item_id" gorm:"not null;index"`
+	GroupID     uint    `json:"group_id" gorm:"not null"`
+	OptionID    uint    `json:"option_id" gorm:"not null"`
+	GroupName   string  `json:"group_name" gorm:"size:100"`
+	OptionName  string  `json:"option_name" gorm:"size:100"`
+	PriceDelta  float64 `json:"price_delta" gorm:"default:0"`
+}
+
+// TableName specifies the table name for OrderItemOption model
+func (OrderItemOption) TableName() string {
+	return "order_item_options"
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderStatusHistory records a single status change of an order
+type OrderStatusHistory struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Change information
+	OrderID       uint   `json:"order_id" gorm:"not null;index"`
+	FromStatus    string `json:"from_status" gorm:"size:20"` // Empty for the creation entry
+	ToStatus      string `json:"to_status" gorm:"not null;size:20"`
+	ChangedByID   *uint  `json:"changed_by_id"` // Nil for system changes
+	ChangedBy     *User  `json:"changed_by,omitempty" gorm:"foreignKey:ChangedByID"`
+	ChangedByRole string `json:"changed_by_role" gorm:"size:20"` // student, stand, admin, system
+	Note          string `json:"note" gorm:"size:255"`
+}
+
+// TableName specifies the table name for OrderStatusHistory model
+func (OrderStatusHistory) TableName() string {
+	return "order_status_histories"
+}
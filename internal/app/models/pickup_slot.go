@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PickupSlot represents a break-time slot a stand accepts pre-orders for
+type PickupSlot struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Slot information
+	StandID   uint   `json:"stand_id" gorm:"not null;index"`
+	Name      string `json:"name" gorm:"not null;size:50"`      // e.g. First break, Lunch
+	StartTime string `json:"start_time" gorm:"not null;size:5"` // HH:MM, school time
+	Capacity  int    `json:"capacity" gorm:"not null"`          // Maximum orders per slot per day
+	IsActive  bool   `json:"is_active" gorm:"default:true"`
+}
+
+// TableName specifies the table name for PickupSlot model
+func (PickupSlot) TableName() string {
+	return "pickup_slots"
+}
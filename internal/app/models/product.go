@@ -25,6 +25,7 @@ type Product struct {
 	DiscountedPrice float64 `json:"discounted_price" gorm:"-"` // Calculated field, not stored in DB
 	IsActive    bool    `json:"is_active" gorm:"default:true"`
 	StandID      uint    `json:"stand_id" gorm:"not null;index"` // Canteen stand ID
+	OptionGroups []ProductOptionGroup `json:"option_groups,omitempty" gorm:"foreignKey:ProductID"`
 }
 
 // TableName specifies the table name for Product model
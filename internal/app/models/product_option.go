@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductOptionGroup is a set of choices for a product, e.g. spice level or toppings
+type ProductOptionGroup struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Group information
+	ProductID     uint            `json:"product_id" gorm:"not null;index"`
+	Name          string          `json:"name" gorm:"not null;size:100"`
+	SelectionType string          `json:"selection_type" gorm:"not null;size:10;default:'single'"` // single, multi
+	IsRequired    bool            `json:"is_required" gorm:"default:false"`
+	MinSelect     int             `json:"min_select" gorm:"default:0"` // Minimum options to pick; at least 1 when required
+	MaxSelect     int             `json:"max_select" gorm:"default:0"` // Maximum options to pick, 0 means no limit; always 1 for single
+	SortOrder     int             `json:"sort_order" gorm:"default:0"`
+	Options       []ProductOption `json:"options" gorm:"foreignKey:GroupID"`
+}
+
+// TableName specifies the table name for ProductOptionGroup model
+func (ProductOptionGroup) TableName() string {
+	return "product_option_groups"
+}
+
+// ProductOption is one choice of an option group with its price change
+type ProductOption struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Option information
+	GroupID    uint    `json:"group_id" gorm:"not null;index"`
+	Name       string  `json:"name" gorm:"not null;size:100"`
+	PriceDelta float64 `json:"price_delta" gorm:"default:0"` // Added to the unit price, may be negative
+	IsActive   bool    `json:"is_active" gorm:"default:true"`
+	SortOrder  int     `json:"sort_order" gorm:"default:0"`
+}
+
+// TableName specifies the table name for ProductOption model
+func (ProductOption) TableName() string {
+	return "product_options"
+}
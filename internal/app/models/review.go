@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Review is a student's 1-5 rating of an order item, or of the stand for an order
+type Review struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Review information
+	OrderID     uint     `json:"order_id" gorm:"not null;uniqueIndex:idx_review_order_item"`
+	OrderItemID uint     `json:"order_item_id" gorm:"not null;default:0;uniqueIndex:idx_review_order_item"` // 0 for the stand review of the order
+	UserID      uint     `json:"user_id" gorm:"not null;index"`
+	User        User     `json:"user" gorm:"foreignKey:UserID"`
+	StandID     uint     `json:"stand_id" gorm:"not null;index"`
+	ProductID   *uint    `json:"product_id,omitempty" gorm:"index"` // Nil for stand reviews
+	Product     *Product `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	Rating      int      `json:"rating" gorm:"not null"` // 1-5
+	Comment     string   `json:"comment" gorm:"size:500"`
+
+	// Stand reply
+	Reply     string     `json:"reply,omitempty" gorm:"size:500"`
+	RepliedAt *time.Time `json:"replied_at,omitempty"`
+
+	// Moderation
+	IsHidden     bool       `json:"is_hidden" gorm:"default:false;index"` // Hidden reviews are left out of listings and averages
+	HiddenReason string     `json:"hidden_reason,omitempty" gorm:"size:255"`
+	HiddenByID   *uint      `json:"hidden_by_id,omitempty"`
+	HiddenAt     *time.Time `json:"hidden_at,omitempty"`
+}
+
+// TableName specifies the table name for Review model
+func (Review) TableName() string {
+	return "reviews"
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrSettlementImmutable is returned when trying to delete a closed settlement
+var ErrSettlementImmutable = errors.New("closed settlements cannot be deleted")
+
+// Settlement represents a closed settlement statement for a stand over a period
+type Settlement struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Settlement period
+	SettlementNumber string    `json:"settlement_number" gorm:"uniqueIndex;not null;size:50"`
+	StandID          uint      `json:"stand_id" gorm:"not null;index"`
+	Stand            User      `json:"stand" gorm:"foreignKey:StandID"`
+	PeriodStart      time.Time `json:"period_start" gorm:"not null"`
+	PeriodEnd        time.Time `json:"period_end" gorm:"not null;index"`
+
+	// Totals
+	OrderCount     int     `json:"order_count"`
+	WalletSales    float64 `json:"wallet_sales"`    // Completed orders paid by card/wallet, before item refunds
+	QRISSales      float64 `json:"qris_sales"`      // Completed orders paid by QRIS (collected by stand)
+	CashSales      float64 `json:"cash_sales"`      // Completed orders paid by cash (collected by stand)
+	GrossSales     float64 `json:"gross_sales"`     // WalletSales + QRISSales + CashSales
+	Refunds        float64 `json:"refunds"`         // Wallet refunds on the included orders
+	CommissionRate float64 `json:"commission_rate"` // Percentage applied to GrossSales - Refunds
+	Commission     float64 `json:"commission"`
+	NetRevenue     float64 `json:"net_revenue"`   // GrossSales - Refunds - Commission
+	PayoutAmount   float64 `json:"payout_amount"` // WalletSales - Refunds - Commission, owed by the school
+
+	// Audit
+	ClosedByID    uint             `json:"closed_by_id"`
+	ClosedBy      User             `json:"closed_by" gorm:"foreignKey:ClosedByID"`
+	PayoutBatchID *uint            `json:"payout_batch_id" gorm:"index"`
+	Lines         []SettlementLine `json:"lines,omitempty" gorm:"foreignKey:SettlementID"`
+}
+
+// SettlementLine represents a single order or refund included in a settlement
+type SettlementLine struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Line information
+	SettlementID  uint      `json:"settlement_id" gorm:"not null;index"`
+	Type          string    `json:"type" gorm:"not null;size:20"` // sale, refund
+	OrderID       *uint     `json:"order_id"`
+	TransactionID *uint     `json:"transaction_id"`
+	Reference     string    `json:"reference" gorm:"size:50"` // Order or transaction number
+	PaymentMethod string    `json:"payment_method" gorm:"size:20"`
+	Amount        float64   `json:"amount" gorm:"not null"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// PayoutBatch groups closed settlements that are paid out to stands together
+type PayoutBatch struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Batch information
+	BatchNumber string       `json:"batch_number" gorm:"uniqueIndex;not null;size:50"`
+	Status      string       `json:"status" gorm:"not null;size:20;default:'pending'"` // pending, paid
+	TotalAmount float64      `json:"total_amount"`
+	CreatedByID uint         `json:"created_by_id"`
+	PaidByID    *uint        `json:"paid_by_id"`
+	PaidAt      *time.Time   `json:"paid_at"`
+	Reference   string       `json:"reference" gorm:"size:100"` // Bank transfer reference
+	Settlements []Settlement `json:"settlements" gorm:"foreignKey:PayoutBatchID"`
+}
+
+// TableName specifies the table name for Settlement model
+func (Settlement) TableName() string {
+	return "settlements"
+}
+
+// TableName specifies the table name for SettlementLine model
+func (SettlementLine) TableName() string {
+	return "settlement_lines"
+}
+
+// TableName specifies the table name for PayoutBatch model
+func (PayoutBatch) TableName() string {
+	return "payout_batches"
+}
+
+// BeforeDelete hook keeps settlement statements immutable
+func (s *Settlement) BeforeDelete(tx *gorm.DB) (err error) {
+	return ErrSettlementImmutable
+}
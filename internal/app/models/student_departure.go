@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StudentDeparture tracks a graduating or leaving student through wallet cash-out and deactivation
+type StudentDeparture struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Departure information
+	UserID        uint    `json:"user_id" gorm:"not null;index"`
+	User          User    `json:"user" gorm:"foreignKey:UserID"`
+	Class         string  `json:"class" gorm:"size:50;index"`           // Class at the time of flagging
+	Reason        string  `json:"reason" gorm:"not null;size:20"`       // graduated, transferred, left
+	Status        string  `json:"status" gorm:"not null;size:20;index"` // flagged, completed
+	FlaggedByID   uint    `json:"flagged_by_id"`
+	BalanceAtFlag float64 `json:"balance_at_flag"`
+
+	// Cash-out
+	Method        string       `json:"method" gorm:"size:20"` // cash, sibling_transfer, none
+	SiblingID     *uint        `json:"sibling_id"`
+	Sibling       *User        `json:"sibling,omitempty" gorm:"foreignKey:SiblingID"`
+	SettledAmount float64      `json:"settled_amount"`
+	SettledByID   *uint        `json:"settled_by_id"`
+	CompletedAt   *time.Time   `json:"completed_at"`
+	TransactionID *uint        `json:"transaction_id"` // Ledger entry that zeroed the wallet
+	Transaction   *Transaction `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
+}
+
+// TableName specifies the table name for StudentDeparture model
+func (StudentDeparture) TableName() string {
+	return "student_departures"
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TopUpRequest represents a student's request to top up balance via bank transfer
+type TopUpRequest struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Request information
+	UserID   uint    `json:"user_id" gorm:"not null;index"`
+	User     User    `json:"user" gorm:"foreignKey:UserID"`
+	Amount   float64 `json:"amount" gorm:"not null"`
+	ProofURL string  `json:"proof_url" gorm:"type:text"`                             // Path to transfer proof image
+	Status   string  `json:"status" gorm:"not null;size:20;default:'pending';index"` // pending, approved, rejected
+
+	// Review details
+	ReviewedByID  *uint        `json:"reviewed_by_id"`
+	ReviewedBy    *User        `json:"reviewed_by,omitempty" gorm:"foreignKey:ReviewedByID"`
+	ReviewedAt    *time.Time   `json:"reviewed_at"`
+	ReviewNote    string       `json:"review_note" gorm:"size:255"`
+	TransactionID *uint        `json:"transaction_id"` // Ledger entry created on approval
+	Transaction   *Transaction `json:"transaction,omitempty" gorm:"foreignKey:TransactionID"`
+}
+
+// TableName specifies the table name for TopUpRequest model
+func (TopUpRequest) TableName() string {
+	return "top_up_requests"
+}
@@ -17,13 +17,15 @@ type Transaction struct {
 	TransactionNumber string  `json:"transaction_number" gorm:"uniqueIndex;not null;size:50"`
 	UserID            uint    `json:"user_id" gorm:"not null;index"`
 	User              User    `json:"user" gorm:"foreignKey:UserID"`
-	Type              string  `json:"type" gorm:"not null;size:20"` // top_up, purchase, refund
+	Type              string  `json:"type" gorm:"not null;size:20"` // top_up, purchase, refund, adjustment, cash_out, transfer_in, transfer_out
 	Amount            float64 `json:"amount" gorm:"not null"`
 	BalanceBefore     float64 `json:"balance_before" gorm:"not null"`
 	BalanceAfter      float64 `json:"balance_after" gorm:"not null"`
 	Description       string  `json:"description" gorm:"size:255"`
 	OrderID           *uint   `json:"order_id" gorm:"index"` // nullable, reference to order if applicable
 	Order             *Order  `json:"order" gorm:"foreignKey:OrderID"`
+	CheckoutID        *uint   `json:"checkout_id,omitempty" gorm:"index"` // Set on the single debit of a multi-stand checkout
+	SettlementID      *uint   `json:"settlement_id,omitempty" gorm:"index"` // Set once a refund is included in a closed settlement
 }
 
 // TableName specifies the table name for Transaction model
@@ -14,12 +14,12 @@ type User struct {
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	// User information
-	StudentId  string  `json:"student_id" gorm:"size:50;default:''"`
+	StudentId  string  `json:"student_id" gorm:"size:50;default:'';index"`
 	Name       string  `json:"name" gorm:"not null;size:100"`
 	Email      string  `json:"email" gorm:"size:100"`
 	Phone      string  `json:"phone" gorm:"size:20"`
 	Role       string  `json:"role" gorm:"not null;size:20;default:'student'"` // student, teacher, admin, stand
-	Class      string  `json:"class" gorm:"size:50"`
+	Class      string  `json:"class" gorm:"size:50;index"`
 	Balance    float64 `json:"balance" gorm:"default:0"`
 	IsActive   bool    `json:"is_active" gorm:"default:true"`
 	RFIDCard   string  `json:"rfid_card" gorm:"column:rf_id_card;size:50"`
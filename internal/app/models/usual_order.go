@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UsualOrder is a named set of items a student orders regularly
+type UsualOrder struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Usual order information
+	UserID uint             `json:"user_id" gorm:"not null;index"`
+	Name   string           `json:"name" gorm:"not null;size:100"`
+	Items  []UsualOrderItem `json:"items" gorm:"foreignKey:UsualOrderID"`
+}
+
+// TableName specifies the table name for UsualOrder model
+func (UsualOrder) TableName() string {
+	return "usual_orders"
+}
+
+// UsualOrderItem is one product of a usual order. Prices aren't stored; the
+// item is priced when it is put into the cart.
+type UsualOrderItem struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+
+	// Item information
+	UsualOrderID uint    `json:"usual_order_id" gorm:"not null;index"`
+	ProductID    uint    `json:"product_id" gorm:"not null"`
+	Product      Product `json:"product" gorm:"foreignKey:ProductID"`
+	Quantity     int     `json:"quantity" gorm:"not null"`
+	OptionIDs    string  `json:"option_ids" gorm:"size:255"` // Comma-separated product option IDs
+	Note         string  `json:"note" gorm:"size:255"`
+}
+
+// TableName specifies the table name for UsualOrderItem model
+func (UsualOrderItem) TableName() string {
+	return "usual_order_items"
+}
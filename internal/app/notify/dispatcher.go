@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/settings"
+
+	"gorm.io/gorm"
+)
+
+// maxAttempts is how often the dispatcher retries a failing delivery
+const maxAttempts = 5
+
+// Channel delivers notifications to an external destination
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, notification models.Notification) error
+}
+
+// LogChannel writes notifications to the server log
+type LogChannel struct{}
+
+// Name returns the channel name
+func (LogChannel) Name() string { return ChannelLog }
+
+// Send logs the notification
+func (LogChannel) Send(ctx context.Context, notification models.Notification) error {
+	log.Printf("notification #%d to user %d [%s] %s: %s",
+		notification.ID, notification.UserID, notification.Type, notification.Title, notification.Message)
+	return nil
+}
+
+// WebhookChannel posts notifications as JSON to the notification_webhook_url
+// global setting. Only admins set the target, so users can't point the
+// server at internal addresses.
+type WebhookChannel struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+// NewWebhookChannel creates a new WebhookChannel instance
+func NewWebhookChannel(db *gorm.DB) *WebhookChannel {
+	return &WebhookChannel{db: db, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name returns the channel name
+func (w *WebhookChannel) Name() string { return ChannelWebhook }
+
+// Send posts the notification and fails on any non-2xx response
+func (w *WebhookChannel) Send(ctx context.Context, notification models.Notification) error {
+	url := settings.GetString(w.db, "notification_webhook_url", "")
+	if url == "" {
+		return fmt.Errorf("no webhook URL configured")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":         notification.ID,
+		"user_id":    notification.UserID,
+		"type":       notification.Type,
+		"title":      notification.Title,
+		"message":    notification.Message,
+		"data":       json.RawMessage(notification.Data),
+		"created_at": notification.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Dispatcher delivers queued notifications to their external channels. The
+// jobs scheduler calls DispatchPending on its interval.
+type Dispatcher struct {
+	db       *gorm.DB
+	channels map[string]Channel
+}
+
+// NewDispatcher creates a dispatcher with the given channels
+func NewDispatcher(db *gorm.DB, channels ...Channel) *Dispatcher {
+	d := &Dispatcher{db: db, channels: make(map[string]Channel)}
+	for _, channel := range channels {
+		d.channels[channel.Name()] = channel
+	}
+	return d
+}
+
+// DispatchPending delivers every undelivered notification once
+func (d *Dispatcher) DispatchPending(ctx context.Context) error {
+	var pending []models.Notification
+	if err := d.db.Where("delivered_at IS NULL AND channels <> '' AND attempts < ?", maxAttempts).
+		Order("id ASC").Limit(100).Find(&pending).Error; err != nil {
+		return err
+	}
+
+	for _, notification := range pending {
+		updates := map[string]interface{}{"attempts": notification.Attempts + 1}
+
+		// All channels are retried together; receivers should dedupe on the notification ID
+		var failures []string
+		for _, name := range strings.Split(notification.Channels, ",") {
+			channel, ok := d.channels[name]
+			if !ok {
+				continue
+			}
+			if err := channel.Send(ctx, notification); err != nil {
+				failures = append(failures, name+": "+err.Error())
+			}
+		}
+
+		if len(failures) == 0 {
+			updates["delivered_at"] = time.Now()
+			updates["last_error"] = ""
+		} else {
+			message := strings.Join(failures, "; ")
+			if len(message) > 255 {
+				message = message[:255]
+			}
+			updates["last_error"] = message
+		}
+
+		if err := d.db.Model(&models.Notification{}).Where("id = ?", notification.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/settings"
+
+	"gorm.io/gorm"
+)
+
+// Notification types
+const (
+	LowBalance    = "low_balance"
+	LargePurchase = "large_purchase"
+	OrderReady    = "order_ready"
+	OrderExpired  = "order_expired"
+	ReviewReply   = "review_reply"
+	OrderChanged  = "order_changed"
+)
+
+// Channel names
+const (
+	ChannelInbox   = "inbox"
+	ChannelWebhook = "webhook"
+	ChannelLog     = "log"
+)
+
+// Default thresholds used when neither the user nor the global settings set one
+const (
+	DefaultLowBalanceThreshold    = 10000
+	DefaultLargePurchaseThreshold = 50000
+)
+
+// Message is a notification to be queued for a user
+type Message struct {
+	UserID  uint
+	Type    string
+	Title   string
+	Message string
+	Data    map[string]interface{}
+}
+
+// Queue stores the message in the user's inbox and outbox according to their
+// channel preferences. Run it inside the transaction that caused the event so
+// the notification is only kept when that transaction commits.
+func Queue(tx *gorm.DB, pref models.NotificationPreference, msg Message) error {
+	inbox := false
+	var external []string
+	for _, channel := range channels(tx, pref) {
+		if channel == ChannelInbox {
+			inbox = true
+		} else {
+			external = append(external, channel)
+		}
+	}
+	if !inbox && len(external) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return err
+	}
+
+	notification := models.Notification{
+		UserID:   msg.UserID,
+		Type:     msg.Type,
+		Title:    msg.Title,
+		Message:  msg.Message,
+		Data:     string(data),
+		InInbox:  inbox,
+		Channels: strings.Join(external, ","),
+	}
+	return tx.Create(&notification).Error
+}
+
+// CheckTransaction queues low-balance and large-purchase notifications for a
+// freshly written ledger row. It is called by the wallet for every balance change.
+func CheckTransaction(tx *gorm.DB, transaction models.Transaction) error {
+	pref := Preference(tx, transaction.UserID)
+
+	large := threshold(tx, pref.LargePurchaseThreshold, "large_purchase_threshold", DefaultLargePurchaseThreshold)
+	if transaction.Type == "purchase" && large > 0 && transaction.Amount > large {
+		if err := Queue(tx, pref, Message{
+			UserID:  transaction.UserID,
+			Type:    LargePurchase,
+			Title:   "Large purchase",
+			Message: fmt.Sprintf("A purchase of Rp %.0f was made with your card (%s).", transaction.Amount, transaction.TransactionNumber),
+			Data: map[string]interface{}{
+				"transaction_number": transaction.TransactionNumber,
+				"amount":             transaction.Amount,
+				"threshold":          large,
+				"balance":            transaction.BalanceAfter,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Only notify when the balance crosses the threshold, not on every debit below it
+	low := threshold(tx, pref.LowBalanceThreshold, "low_balance_threshold", DefaultLowBalanceThreshold)
+	if low > 0 && transaction.BalanceBefore >= low && transaction.BalanceAfter < low {
+		if err := Queue(tx, pref, Message{
+			UserID:  transaction.UserID,
+			Type:    LowBalance,
+			Title:   "Low balance",
+			Message: fmt.Sprintf("Your balance is Rp %.0f, below Rp %.0f. Please top up.", transaction.BalanceAfter, low),
+			Data: map[string]interface{}{
+				"transaction_number": transaction.TransactionNumber,
+				"balance":            transaction.BalanceAfter,
+				"threshold":          low,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Preference returns the user's notification preference, or an empty one
+// (global defaults) if the user has not saved any
+func Preference(db *gorm.DB, userID uint) models.NotificationPreference {
+	var pref models.NotificationPreference
+	if err := db.Where("user_id = ?", userID).First(&pref).Error; err != nil {
+		return models.NotificationPreference{UserID: userID}
+	}
+	return pref
+}
+
+// ValidChannel reports whether name is a known channel
+func ValidChannel(name string) bool {
+	return name == ChannelInbox || name == ChannelWebhook || name == ChannelLog
+}
+
+// channels returns the user's channels, falling back to the notification_channels setting
+func channels(db *gorm.DB, pref models.NotificationPreference) []string {
+	value := pref.Channels
+	if value == "" {
+		value = settings.GetString(db, "notification_channels", ChannelInbox)
+	}
+
+	var result []string
+	for _, channel := range strings.Split(value, ",") {
+		channel = strings.TrimSpace(channel)
+		if ValidChannel(channel) {
+			result = append(result, channel)
+		}
+	}
+	return result
+}
+
+// threshold returns the user's value, the global setting, or the default in that order
+func threshold(db *gorm.DB, value *float64, key string, defaultValue float64) float64 {
+	if value != nil {
+		return *value
+	}
+	return settings.GetFloat(db, key, defaultValue)
+}
@@ -0,0 +1,96 @@
+package numbering
+
+import (
+	"fmt"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/settings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Document types
+const (
+	Order       = "ORD"
+	Checkout    = "CHK" // Multi-stand cart checkouts, school-wide
+	Purchase    = "PUR"
+	TopUp       = "TOPUP"
+	Refund      = "REF"
+	Adjustment  = "ADJ"
+	CashOut     = "CO"
+	Transfer    = "TRF"
+	Settlement  = "STL"
+	PayoutBatch = "PAY"
+	Queue       = "QUE" // Daily pickup queue numbers, see NextQueue
+)
+
+// Next issues the next number for a document type, e.g. ORD-S03-20261016-0042.
+// Numbers are sequential per type, per stand and per day; pass standID 0 for
+// school-wide documents (TOPUP-20261016-0007).
+//
+// The counter row stays locked until the surrounding transaction finishes, so
+// concurrent callers never receive the same number and a rolled back
+// transaction does not leave a gap.
+func Next(db *gorm.DB, docType string, standID uint) (string, error) {
+	scope := ""
+	if standID != 0 {
+		scope = fmt.Sprintf("S%02d", standID)
+	}
+	day := today(db)
+
+	value, err := increment(db, docType, scope, day)
+	if err != nil {
+		return "", err
+	}
+
+	if scope == "" {
+		return fmt.Sprintf("%s-%s-%04d", docType, day, value), nil
+	}
+	return fmt.Sprintf("%s-%s-%s-%04d", docType, scope, day, value), nil
+}
+
+// NextQueue issues a stand's next daily pickup queue number: A-001 to A-999,
+// then B-001 and so on. It shares the document_sequences counters with Next.
+func NextQueue(db *gorm.DB, standID uint) (string, error) {
+	value, err := increment(db, Queue, fmt.Sprintf("S%02d", standID), today(db))
+	if err != nil {
+		return "", err
+	}
+
+	letter := 'A' + rune((value-1)/999%26)
+	return fmt.Sprintf("%c-%03d", letter, (value-1)%999+1), nil
+}
+
+// today returns the current date in the school time zone, so the daily
+// counters restart at the school's midnight and not the server's
+func today(db *gorm.DB) string {
+	return time.Now().In(settings.GetLocation(db)).Format("20060102")
+}
+
+// increment bumps the counter for a type, scope and day and returns the new value
+func increment(db *gorm.DB, docType, scope, day string) (int, error) {
+	now := time.Now()
+
+	var sequence models.DocumentSequence
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Insert the first counter of the day or increment the existing one atomically
+		if err := tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"last_value": gorm.Expr("last_value + 1"),
+				"updated_at": now,
+			}),
+		}).Create(&models.DocumentSequence{DocType: docType, Scope: scope, Day: day, LastValue: 1}).Error; err != nil {
+			return err
+		}
+
+		return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("doc_type = ? AND scope = ? AND day = ?", docType, scope, day).
+			First(&sequence).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to issue %s number: %w", docType, err)
+	}
+	return sequence.LastValue, nil
+}
@@ -0,0 +1,276 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"swipeup-admin-v2/internal/app/models"
+
+	"gorm.io/gorm"
+)
+
+// Selection types
+const (
+	Single = "single"
+	Multi  = "multi"
+)
+
+var (
+	// ErrUnknownOption is returned for an option that isn't an active option of the product
+	ErrUnknownOption = errors.New("unknown option")
+	// ErrTooFewOptions is returned when a group has fewer choices than its minimum
+	ErrTooFewOptions = errors.New("too few options selected")
+	// ErrTooManyOptions is returned when a group has more choices than its maximum
+	ErrTooManyOptions = errors.New("too many options selected")
+	// ErrInvalidGroup is returned for an option group with inconsistent settings
+	ErrInvalidGroup = errors.New("invalid option group")
+)
+
+// Selection is an option chosen for an item
+type Selection struct {
+	GroupID    uint
+	GroupName  string
+	OptionID   uint
+	OptionName string
+	PriceDelta float64
+}
+
+// SelectionError describes which group or option a selection failed on
+type SelectionError struct {
+	Err      error
+	Group    string
+	OptionID uint
+	Limit    int
+}
+
+// Error implements the error interface
+func (e *SelectionError) Error() string {
+	switch e.Err {
+	case ErrUnknownOption:
+		return fmt.Sprintf("Option %d is not available for this product", e.OptionID)
+	case ErrTooFewOptions:
+		return fmt.Sprintf("Choose at least %d option(s) for %s", e.Limit, e.Group)
+	default:
+		return fmt.Sprintf("Choose at most %d option(s) for %s", e.Limit, e.Group)
+	}
+}
+
+// Unwrap returns the underlying sentinel error
+func (e *SelectionError) Unwrap() error {
+	return e.Err
+}
+
+// Limits returns the effective minimum and maximum number of choices for a
+// group; a maximum of 0 means no limit.
+func Limits(group models.ProductOptionGroup) (int, int) {
+	min, max := group.MinSelect, group.MaxSelect
+	if group.IsRequired && min < 1 {
+		min = 1
+	}
+	if group.SelectionType == Single {
+		max = 1
+	}
+	return min, max
+}
+
+// Validate checks the settings of an option group before it is saved
+func Validate(group models.ProductOptionGroup) error {
+	if group.SelectionType != Single && group.SelectionType != Multi {
+		return fmt.Errorf("%w: selection_type must be single or multi", ErrInvalidGroup)
+	}
+	if group.MinSelect < 0 || group.MaxSelect < 0 {
+		return fmt.Errorf("%w: min_select and max_select can't be negative", ErrInvalidGroup)
+	}
+	min, max := Limits(group)
+	if max > 0 && min > max {
+		return fmt.Errorf("%w: min_select is larger than max_select", ErrInvalidGroup)
+	}
+	if min > len(group.Options) {
+		return fmt.Errorf("%w: group has fewer options than min_select", ErrInvalidGroup)
+	}
+	return nil
+}
+
+// Resolve checks the chosen option IDs against the product's option groups and
+// returns the selections, ordered like the menu, and the sum of their price deltas.
+func Resolve(db *gorm.DB, productID uint, optionIDs []uint) ([]Selection, float64, error) {
+	var groups []models.ProductOptionGroup
+	if err := db.Where("product_id = ?", productID).
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Where("is_active = ?", true).Order("sort_order, id")
+		}).
+		Order("sort_order, id").
+		Find(&groups).Error; err != nil {
+		return nil, 0, err
+	}
+
+	chosen := make(map[uint]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		chosen[id] = true
+	}
+
+	var selections []Selection
+	var delta float64
+	found := 0
+	for _, group := range groups {
+		count := 0
+		for _, option := range group.Options {
+			if !chosen[option.ID] {
+				continue
+			}
+			count++
+			selections = append(selections, Selection{
+				GroupID:    group.ID,
+				GroupName:  group.Name,
+				OptionID:   option.ID,
+				OptionName: option.Name,
+				PriceDelta: option.PriceDelta,
+			})
+			delta += option.PriceDelta
+		}
+		found += count
+
+		min, max := Limits(group)
+		if count < min {
+			return nil, 0, &SelectionError{Err: ErrTooFewOptions, Group: group.Name, Limit: min}
+		}
+		if max > 0 && count > max {
+			return nil, 0, &SelectionError{Err: ErrTooManyOptions, Group: group.Name, Limit: max}
+		}
+	}
+
+	if found < len(chosen) {
+		for id := range chosen {
+			if !contains(selections, id) {
+				return nil, 0, &SelectionError{Err: ErrUnknownOption, OptionID: id}
+			}
+		}
+	}
+	return selections, delta, nil
+}
+
+// UnitPrice returns the product's discounted price plus the option deltas,
+// never below zero. Discounts apply to the base price only.
+func UnitPrice(product models.Product, delta float64) float64 {
+	price := product.Price
+	if product.Discount > 0 {
+		price = product.Price * (1 - product.Discount/100)
+	}
+	price += delta
+	if price < 0 {
+		return 0
+	}
+	return price
+}
+
+// Key returns a stable key for a set of selections, used to merge identical cart lines
+func Key(selections []Selection) string {
+	ids := make([]uint, 0, len(selections))
+	for _, selection := range selections {
+		ids = append(ids, selection.OptionID)
+	}
+	return KeyOf(ids)
+}
+
+// KeyOf returns the key for a set of option IDs: sorted and comma-separated
+func KeyOf(optionIDs []uint) string {
+	ids := make([]int, 0, len(optionIDs))
+	for _, id := range optionIDs {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseKey returns the option IDs of a key made by Key, skipping invalid parts
+func ParseKey(key string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(key, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err == nil && id != 0 {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// OrderOptionIDs returns the option IDs chosen for an order item
+func OrderOptionIDs(itemOptions []models.OrderItemOption) []uint {
+	ids := make([]uint, 0, len(itemOptions))
+	for _, o := range itemOptions {
+		ids = append(ids, o.OptionID)
+	}
+	return ids
+}
+
+// CartItemOptions converts selections to cart item options
+func CartItemOptions(selections []Selection) []models.CartItemOption {
+	result := make([]models.CartItemOption, 0, len(selections))
+	for _, s := range selections {
+		result = append(result, models.CartItemOption{
+			GroupID:    s.GroupID,
+			OptionID:   s.OptionID,
+			GroupName:  s.GroupName,
+			OptionName: s.OptionName,
+			PriceDelta: s.PriceDelta,
+		})
+	}
+	return result
+}
+
+// OrderItemOptions converts selections to order item options
+func OrderItemOptions(selections []Selection) []models.OrderItemOption {
+	result := make([]models.OrderItemOption, 0, len(selections))
+	for _, s := range selections {
+		result = append(result, models.OrderItemOption{
+			GroupID:    s.GroupID,
+			OptionID:   s.OptionID,
+			GroupName:  s.GroupName,
+			OptionName: s.OptionName,
+			PriceDelta: s.PriceDelta,
+		})
+	}
+	return result
+}
+
+// FromCartItem copies a cart item's options to order item options at checkout
+func FromCartItem(cartOptions []models.CartItemOption) []models.OrderItemOption {
+	result := make([]models.OrderItemOption, 0, len(cartOptions))
+	for _, o := range cartOptions {
+		result = append(result, models.OrderItemOption{
+			GroupID:    o.GroupID,
+			OptionID:   o.OptionID,
+			GroupName:  o.GroupName,
+			OptionName: o.OptionName,
+			PriceDelta: o.PriceDelta,
+		})
+	}
+	return result
+}
+
+// Describe formats options for receipts and kitchen tickets, e.g. "Spice: Level 3, Topping: Egg"
+func Describe(itemOptions []models.OrderItemOption) string {
+	parts := make([]string, 0, len(itemOptions))
+	for _, o := range itemOptions {
+		parts = append(parts, o.GroupName+": "+o.OptionName)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// contains reports whether an option ID was selected
+func contains(selections []Selection, optionID uint) bool {
+	for _, s := range selections {
+		if s.OptionID == optionID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,302 @@
+// Package ordersearch filters, sorts and pages orders for the stand and
+// admin order lists. Pages are fetched with a cursor on (sort column, id)
+// rather than an offset, so paging stays fast and stable while new orders
+// come in.
+package ordersearch
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/orderstatus"
+
+	"gorm.io/gorm"
+)
+
+// Page size limits
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// sortColumns are the columns orders can be sorted by
+var sortColumns = map[string]string{
+	"created_at":   "orders.created_at",
+	"total_amount": "orders.total_amount",
+	"order_number": "orders.order_number",
+}
+
+var paymentMethods = map[string]bool{"card": true, "cash": true, "qris": true}
+
+// ErrInvalidCursor is returned for a cursor that wasn't made by this search
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Filter is a parsed order search
+type Filter struct {
+	StandID        uint // Zero searches every stand
+	OrderNumber    string
+	Student        string // Name or NIS
+	Class          string
+	Statuses       []string
+	PaymentMethods []string
+	From           *time.Time // Inclusive
+	To             *time.Time // Exclusive
+	MinAmount      *float64
+	MaxAmount      *float64
+	Sort           string
+	Desc           bool
+	Limit          int
+	Cursor         string
+}
+
+// Page is one page of results
+type Page struct {
+	Orders     []models.Order `json:"orders"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	HasMore    bool           `json:"has_more"`
+	Limit      int            `json:"limit"`
+}
+
+// cursor is the position after the last order of a page
+type cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// Parse reads a filter from query parameters:
+//   - order_number: order number prefix
+//   - student: name contains or NIS prefix; class: exact class
+//   - status, payment_method: comma separated lists
+//   - from, to: YYYY-MM-DD (to inclusive) or YYYY-MM-DDTHH:MM[:SS] (to exclusive),
+//     in the school time zone loc
+//   - min_amount, max_amount: total amount range
+//   - sort: created_at (default), total_amount or order_number; order: desc (default) or asc
+//   - limit (default 20, max 100) and cursor from the previous page
+func Parse(values url.Values, loc *time.Location) (Filter, error) {
+	f := Filter{
+		OrderNumber: strings.ToUpper(strings.TrimSpace(values.Get("order_number"))),
+		Student:     strings.TrimSpace(values.Get("student")),
+		Class:       strings.TrimSpace(values.Get("class")),
+		Sort:        values.Get("sort"),
+		Desc:        values.Get("order") != "asc",
+		Limit:       DefaultLimit,
+		Cursor:      values.Get("cursor"),
+	}
+
+	for _, status := range list(values.Get("status")) {
+		if !orderstatus.Valid(status) {
+			return f, fmt.Errorf("invalid status: %s", status)
+		}
+		f.Statuses = append(f.Statuses, status)
+	}
+	for _, method := range list(values.Get("payment_method")) {
+		if !paymentMethods[method] {
+			return f, fmt.Errorf("invalid payment_method: %s", method)
+		}
+		f.PaymentMethods = append(f.PaymentMethods, method)
+	}
+
+	if value := values.Get("from"); value != "" {
+		from, _, err := parseTime(value, loc)
+		if err != nil {
+			return f, errors.New("invalid from, use YYYY-MM-DD or YYYY-MM-DDTHH:MM")
+		}
+		f.From = &from
+	}
+	if value := values.Get("to"); value != "" {
+		to, dateOnly, err := parseTime(value, loc)
+		if err != nil {
+			return f, errors.New("invalid to, use YYYY-MM-DD or YYYY-MM-DDTHH:MM")
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		f.To = &to
+	}
+
+	for name, target := range map[string]**float64{"min_amount": &f.MinAmount, "max_amount": &f.MaxAmount} {
+		if value := values.Get(name); value != "" {
+			amount, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return f, fmt.Errorf("invalid %s", name)
+			}
+			*target = &amount
+		}
+	}
+
+	if f.Sort == "" {
+		f.Sort = "created_at"
+	}
+	if _, ok := sortColumns[f.Sort]; !ok {
+		return f, errors.New("sort must be created_at, total_amount or order_number")
+	}
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return f, errors.New("invalid limit")
+		}
+		if limit > MaxLimit {
+			limit = MaxLimit
+		}
+		f.Limit = limit
+	}
+
+	return f, nil
+}
+
+// Search runs the filter. db may carry preloads for the returned orders.
+func Search(db *gorm.DB, f Filter) (*Page, error) {
+	column := sortColumns[f.Sort]
+	query := db.Model(&models.Order{})
+
+	if f.StandID != 0 {
+		query = query.Where("orders.stand_id = ?", f.StandID)
+	}
+	if f.OrderNumber != "" {
+		query = query.Where("orders.order_number LIKE ?", escapeLike(f.OrderNumber)+"%")
+	}
+	if f.Student != "" || f.Class != "" {
+		students := db.Session(&gorm.Session{NewDB: true}).Model(&models.User{}).Select("id")
+		if f.Student != "" {
+			students = students.Where("(name LIKE ? OR student_id LIKE ?)", "%"+escapeLike(f.Student)+"%", escapeLike(f.Student)+"%")
+		}
+		if f.Class != "" {
+			students = students.Where("class = ?", f.Class)
+		}
+		query = query.Where("orders.user_id IN (?)", students)
+	}
+	if len(f.Statuses) > 0 {
+		query = query.Where("orders.status IN ?", f.Statuses)
+	}
+	if len(f.PaymentMethods) > 0 {
+		query = query.Where("orders.payment_method IN ?", f.PaymentMethods)
+	}
+	if f.From != nil {
+		query = query.Where("orders.created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("orders.created_at < ?", *f.To)
+	}
+	if f.MinAmount != nil {
+		query = query.Where("orders.total_amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		query = query.Where("orders.total_amount <= ?", *f.MaxAmount)
+	}
+
+	if f.Cursor != "" {
+		after, err := decodeCursor(f.Cursor)
+		if err != nil || after.Sort != f.Sort || after.Desc != f.Desc {
+			return nil, ErrInvalidCursor
+		}
+		value, err := cursorValue(f.Sort, after.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		op := ">"
+		if f.Desc {
+			op = "<"
+		}
+		query = query.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND orders.id %s ?))", column, op, column, op), value, value, after.ID)
+	}
+
+	direction := "ASC"
+	if f.Desc {
+		direction = "DESC"
+	}
+
+	// One extra row tells whether there is a next page
+	var orders []models.Order
+	if err := query.Order(column + " " + direction).
+		Order("orders.id " + direction).
+		Limit(f.Limit + 1).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	page := &Page{Orders: orders, Limit: f.Limit}
+	if len(orders) > f.Limit {
+		page.Orders = orders[:f.Limit]
+		page.HasMore = true
+		page.NextCursor = encodeCursor(f, page.Orders[f.Limit-1])
+	}
+	return page, nil
+}
+
+func encodeCursor(f Filter, last models.Order) string {
+	c := cursor{Sort: f.Sort, Desc: f.Desc, ID: last.ID}
+	switch f.Sort {
+	case "total_amount":
+		c.Value = strconv.FormatFloat(last.TotalAmount, 'f', -1, 64)
+	case "order_number":
+		c.Value = last.OrderNumber
+	default:
+		c.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// cursorValue converts a cursor value back to the sort column's type
+func cursorValue(sort, value string) (interface{}, error) {
+	switch sort {
+	case "total_amount":
+		return strconv.ParseFloat(value, 64)
+	case "order_number":
+		return value, nil
+	default:
+		return time.Parse(time.RFC3339Nano, value)
+	}
+}
+
+// parseTime accepts a date or a date and time in loc and reports whether
+// only a date was given
+func parseTime(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, false, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, errors.New("invalid time")
+}
+
+// list splits a comma separated query value, dropping empty parts
+func list(value string) []string {
+	var parts []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// escapeLike escapes LIKE wildcards in user input
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,367 @@
+package orderstatus
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"swipeup-admin-v2/internal/app/inventory"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/notify"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/settings"
+	"swipeup-admin-v2/internal/app/wallet"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Order statuses
+const (
+	PaymentPending = "payment_pending"
+	Request        = "request"
+	Cooking        = "cooking"
+	Ready          = "ready" // Prepared and waiting for pickup
+	Done           = "done"
+	Cancelled      = "cancelled"
+)
+
+// Refund statuses of cash and QRIS orders
+const (
+	RefundPending  = "pending"
+	RefundRefunded = "refunded"
+)
+
+// Actor roles
+const (
+	RoleStudent = "student"
+	RoleStand   = "stand"
+	RoleAdmin   = "admin"
+	RoleSystem  = "system"
+)
+
+// ErrInvalidStatus is returned for an unknown status
+var ErrInvalidStatus = errors.New("invalid status")
+
+// ErrInvalidTransition is returned when the order cannot move to the requested status
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// ErrNotAllowed is returned when the actor may not perform the transition
+var ErrNotAllowed = errors.New("status change not allowed for this role")
+
+// Actor identifies who changes an order status
+type Actor struct {
+	UserID uint // Zero for system changes
+	Role   string
+}
+
+// transitions lists the statuses each status may move to and the roles allowed to do it
+var transitions = map[string]map[string][]string{
+	PaymentPending: {
+		Request:   {RoleStudent, RoleStand, RoleAdmin}, // Payment proof uploaded or confirmed
+		Cancelled: {RoleStudent, RoleStand, RoleAdmin, RoleSystem},
+	},
+	Request: {
+		Cooking:   {RoleStand, RoleAdmin},
+		Cancelled: {RoleStudent, RoleStand, RoleAdmin},
+	},
+	Cooking: {
+		Ready:     {RoleStand, RoleAdmin},
+		Done:      {RoleStand, RoleAdmin}, // Handed over straight away
+		Cancelled: {RoleStand, RoleAdmin},
+	},
+	Ready: {
+		Done:      {RoleStand, RoleAdmin},
+		Cancelled: {RoleStand, RoleAdmin},
+	},
+	Done:      {},
+	Cancelled: {},
+}
+
+// Valid reports whether status is a known order status
+func Valid(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// Allowed returns the statuses the actor role may move an order in status from to
+func Allowed(from, role string) []string {
+	var result []string
+	for _, to := range []string{PaymentPending, Request, Cooking, Ready, Done, Cancelled} {
+		if roleAllowed(transitions[from][to], role) {
+			result = append(result, to)
+		}
+	}
+	return result
+}
+
+// Check validates a transition without applying it
+func Check(from, to, role string) error {
+	if !Valid(to) {
+		return ErrInvalidStatus
+	}
+	roles, ok := transitions[from][to]
+	if !ok {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, from, to)
+	}
+	if !roleAllowed(roles, role) {
+		return ErrNotAllowed
+	}
+	return nil
+}
+
+// Transition moves the order to a new status, runs the side effects of the
+// target status and records the change. The order row is locked, so the
+// caller's copy is refreshed. It must be called inside a database transaction.
+func Transition(tx *gorm.DB, order *models.Order, to string, actor Actor, note string) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(order, order.ID).Error; err != nil {
+		return err
+	}
+
+	from := order.Status
+	if err := Check(from, to, actor.Role); err != nil {
+		return err
+	}
+
+	switch to {
+	case Request, Cooking:
+		if err := assignQueueNumber(tx, order); err != nil {
+			return err
+		}
+	case Ready:
+		if err := assignQueueNumber(tx, order); err != nil {
+			return err
+		}
+		if err := notifyReady(tx, order); err != nil {
+			return err
+		}
+	case Cancelled:
+		if err := cancel(tx, order); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(order).Update("status", to).Error; err != nil {
+		return err
+	}
+	order.Status = to
+
+	return record(tx, order.ID, from, to, actor, note)
+}
+
+// RecordCreated writes the first timeline entry for a newly created order and
+// assigns its queue number if it goes straight to request
+func RecordCreated(tx *gorm.DB, order *models.Order, actor Actor) error {
+	if order.Status == Request {
+		if err := assignQueueNumber(tx, order); err != nil {
+			return err
+		}
+	}
+	return record(tx, order.ID, "", order.Status, actor, "Order created")
+}
+
+// ConfirmPickup completes a ready order and records how the pickup was confirmed
+// (qr, card or manual). It must be called inside a database transaction.
+func ConfirmPickup(tx *gorm.DB, order *models.Order, actor Actor, method string) error {
+	if err := Transition(tx, order, Done, actor, "Picked up ("+method+")"); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tx.Model(order).Updates(map[string]interface{}{"picked_up_at": now, "pickup_method": method}).Error; err != nil {
+		return err
+	}
+	order.PickedUpAt = &now
+	order.PickupMethod = method
+	return nil
+}
+
+// NewPickupCode returns a random code for the student's pickup QR
+func NewPickupCode() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+// Timeline returns the status history of an order, oldest first
+func Timeline(db *gorm.DB, orderID uint) ([]models.OrderStatusHistory, error) {
+	var history []models.OrderStatusHistory
+	err := db.Where("order_id = ?", orderID).Order("created_at ASC, id ASC").Find(&history).Error
+	return history, err
+}
+
+// assignQueueNumber gives the order a daily queue number and pickup code once.
+// Pre-orders for a later day get theirs when the stand starts on them.
+func assignQueueNumber(tx *gorm.DB, order *models.Order) error {
+	if order.QueueNumber != "" {
+		return nil
+	}
+	if order.ScheduledFor != nil {
+		loc := settings.GetLocation(tx)
+		if order.ScheduledFor.In(loc).Format("20060102") > time.Now().In(loc).Format("20060102") {
+			return nil
+		}
+	}
+
+	queueNumber, err := numbering.NextQueue(tx, order.StandID)
+	if err != nil {
+		return err
+	}
+	pickupCode, err := NewPickupCode()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(order).Updates(map[string]interface{}{"queue_number": queueNumber, "pickup_code": pickupCode}).Error; err != nil {
+		return err
+	}
+	order.QueueNumber = queueNumber
+	order.PickupCode = pickupCode
+	return nil
+}
+
+// notifyReady tells the student the order can be collected
+func notifyReady(tx *gorm.DB, order *models.Order) error {
+	return notify.Queue(tx, notify.Preference(tx, order.UserID), notify.Message{
+		UserID:  order.UserID,
+		Type:    notify.OrderReady,
+		Title:   "Order ready for pickup",
+		Message: fmt.Sprintf("Your order %s is ready. Show your pickup QR or tap your card at the counter.", order.QueueNumber),
+		Data: map[string]interface{}{
+			"order_id":     order.ID,
+			"order_number": order.OrderNumber,
+			"queue_number": order.QueueNumber,
+			"stand_id":     order.StandID,
+		},
+	})
+}
+
+// cancel returns reserved stock and refunds the order: a wallet payment back to
+// the wallet, a paid cash or QRIS order is flagged for the stand to pay back
+func cancel(tx *gorm.DB, order *models.Order) error {
+	var items []models.OrderItem
+	if err := tx.Where("order_id = ?", order.ID).Find(&items).Error; err != nil {
+		return err
+	}
+	lines := make([]inventory.Line, 0, len(items))
+	for _, item := range items {
+		// Substitutes were reserved in place of the product; unavailable units were never served
+		if item.SubstituteProductID != nil {
+			lines = append(lines, inventory.Line{ProductID: *item.SubstituteProductID, Quantity: item.Quantity})
+			continue
+		}
+		lines = append(lines, inventory.Line{ProductID: item.ProductID, Quantity: item.Quantity - item.UnavailableQuantity})
+	}
+	if err := inventory.Release(tx, lines); err != nil {
+		return err
+	}
+
+	// Refund what was debited from the wallet for this order and not refunded yet
+	outstanding, err := WalletOutstanding(tx, order)
+	if err != nil {
+		return err
+	}
+	if outstanding > 0 {
+		transactionNumber, err := numbering.Next(tx, numbering.Refund, order.StandID)
+		if err != nil {
+			return err
+		}
+		if _, err := wallet.Credit(tx, wallet.Entry{
+			UserID:            order.UserID,
+			TransactionNumber: transactionNumber,
+			Type:              "refund",
+			Amount:            outstanding,
+			Description:       "Refund: " + order.OrderNumber + " cancelled",
+			OrderID:           &order.ID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Cash is paid at the counter and QRIS once the proof is in; an unpaid QRIS
+	// order has nothing to refund
+	if order.PaymentMethod == "card" || order.Status == PaymentPending {
+		return nil
+	}
+	return FlagCashRefund(tx, order, order.TotalAmount-math.Max(outstanding, 0))
+}
+
+// FlagCashRefund records that the stand owes the student amount back at the
+// counter for a cash or QRIS order, until it is marked refunded
+func FlagCashRefund(tx *gorm.DB, order *models.Order, amount float64) error {
+	if amount <= 0 {
+		return nil
+	}
+	if err := tx.Model(order).Updates(map[string]interface{}{
+		"pending_refund": gorm.Expr("pending_refund + ?", amount),
+		"refund_status":  RefundPending,
+	}).Error; err != nil {
+		return err
+	}
+	order.PendingRefund += amount
+	order.RefundStatus = RefundPending
+	return nil
+}
+
+// WalletOutstanding returns what the student paid for the order from their
+// wallet and hasn't been refunded yet. Orders of a multi-stand checkout share
+// one debit; each order's share is its total before any item refunds.
+func WalletOutstanding(tx *gorm.DB, order *models.Order) (float64, error) {
+	var paid, refunded float64
+	if err := tx.Model(&models.Transaction{}).
+		Where("order_id = ? AND type = ?", order.ID, "purchase").
+		Select("COALESCE(SUM(amount), 0)").Scan(&paid).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.Transaction{}).
+		Where("order_id = ? AND type = ?", order.ID, "refund").
+		Select("COALESCE(SUM(amount), 0)").Scan(&refunded).Error; err != nil {
+		return 0, err
+	}
+
+	if order.CheckoutID != nil {
+		var groupDebits int64
+		if err := tx.Model(&models.Transaction{}).
+			Where("checkout_id = ? AND order_id IS NULL AND type = ?", *order.CheckoutID, "purchase").
+			Count(&groupDebits).Error; err != nil {
+			return 0, err
+		}
+		if groupDebits > 0 {
+			paid += order.TotalAmount + refunded
+		}
+	}
+
+	return paid - refunded, nil
+}
+
+// record writes a status history row
+func record(tx *gorm.DB, orderID uint, from, to string, actor Actor, note string) error {
+	entry := models.OrderStatusHistory{
+		OrderID:       orderID,
+		FromStatus:    from,
+		ToStatus:      to,
+		ChangedByRole: actor.Role,
+		Note:          note,
+	}
+	if actor.UserID != 0 {
+		userID := actor.UserID
+		entry.ChangedByID = &userID
+	}
+	return tx.Create(&entry).Error
+}
+
+// roleAllowed reports whether role is in roles
+func roleAllowed(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+// Package pdf writes simple text-only PDF documents using the standard
+// Helvetica and Courier fonts, without embedding any font data.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page sizes in points (1/72 inch)
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+	MM       = 72 / 25.4
+)
+
+// Fonts available in every PDF reader
+const (
+	Helvetica     = "F1"
+	HelveticaBold = "F2"
+	Courier       = "F3"
+)
+
+var fontNames = map[string]string{
+	Helvetica:     "Helvetica",
+	HelveticaBold: "Helvetica-Bold",
+	Courier:       "Courier",
+}
+
+// Document is a PDF being built page by page
+type Document struct {
+	width  float64
+	height float64
+	pages  []*bytes.Buffer
+}
+
+// New creates an empty document with the given page size in points
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// Width returns the page width in points
+func (d *Document) Width() float64 { return d.width }
+
+// Height returns the page height in points
+func (d *Document) Height() float64 { return d.height }
+
+// AddPage starts a new page; later drawing calls go to this page
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// Text draws text with its baseline at (x, y), measured from the top-left corner
+func (d *Document) Text(x, y float64, font string, size float64, text string) {
+	page := d.current()
+	fmt.Fprintf(page, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.height-y, escape(text))
+}
+
+// TextRight draws text right-aligned to x. Width is estimated, exact for Courier.
+func (d *Document) TextRight(x, y float64, font string, size float64, text string) {
+	d.Text(x-TextWidth(font, size, text), y, font, size, text)
+}
+
+// Line draws a straight line, measured from the top-left corner
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+	page := d.current()
+	fmt.Fprintf(page, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, d.height-y1, x2, d.height-y2)
+}
+
+// Bytes serializes the document
+func (d *Document) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// 1: catalog, 2: page tree, 3-5: fonts, then a page and content stream per page
+	fontIDs := []string{Helvetica, HelveticaBold, Courier}
+	firstPage := 3 + len(fontIDs)
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	fontRefs := make([]string, len(fontIDs))
+	for i, id := range fontIDs {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontNames[id]))
+		fontRefs[i] = fmt.Sprintf("/%s %d 0 R", id, 3+i)
+	}
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			d.width, d.height, strings.Join(fontRefs, " "), firstPage+i*2+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// TextWidth estimates the rendered width of text in points
+func TextWidth(font string, size float64, text string) float64 {
+	perChar := 0.5 // Average Helvetica glyph width
+	if font == Courier {
+		perChar = 0.6
+	}
+	return float64(len([]rune(text))) * perChar * size
+}
+
+func (d *Document) current() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// escape converts text to a WinAnsi PDF string literal body
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 256:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
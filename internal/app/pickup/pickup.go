@@ -0,0 +1,160 @@
+package pickup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/settings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Defaults used when the global settings are missing
+const (
+	DefaultMaxDaysAhead        = 3
+	DefaultCancelCutoffMinutes = 60
+)
+
+var (
+	// ErrSlotNotFound is returned for an unknown or inactive slot of the stand
+	ErrSlotNotFound = errors.New("pickup slot not found")
+	// ErrSlotFull is returned when the slot has reached its capacity for the day
+	ErrSlotFull = errors.New("pickup slot is full")
+	// ErrSlotClosed is returned when the slot time has already passed
+	ErrSlotClosed = errors.New("pickup slot has already started")
+	// ErrTooFarAhead is returned when the date is beyond the pre-order window
+	ErrTooFarAhead = errors.New("pickup date is too far ahead")
+	// ErrCancelCutoff is returned when a pre-order is cancelled after the cutoff
+	ErrCancelCutoff = errors.New("cancellation cutoff has passed")
+)
+
+// Availability is a slot with its remaining capacity on a given day
+type Availability struct {
+	models.PickupSlot
+	ScheduledFor time.Time `json:"scheduled_for"`
+	Booked       int64     `json:"booked"`
+	Remaining    int64     `json:"remaining"`
+	Open         bool      `json:"open"` // False once the slot has started
+}
+
+// ParseStartTime validates an HH:MM slot start time
+func ParseStartTime(value string) (time.Time, error) {
+	return time.Parse("15:04", value)
+}
+
+// ParseDate parses a YYYY-MM-DD pickup date in school time (settings.GetLocation)
+func ParseDate(value string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+// SlotTime returns the start of the slot on the given school date
+func SlotTime(slot models.PickupSlot, date time.Time, loc *time.Location) (time.Time, error) {
+	start, err := ParseStartTime(slot.StartTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	y, m, d := date.In(loc).Date()
+	return time.Date(y, m, d, start.Hour(), start.Minute(), 0, 0, loc), nil
+}
+
+// MaxDaysAhead returns how many days ahead students may pre-order
+func MaxDaysAhead(db *gorm.DB) int {
+	return int(settings.GetFloat(db, "preorder_max_days_ahead", DefaultMaxDaysAhead))
+}
+
+// Reserve checks that the slot can take one more order on the date and
+// returns the scheduled pickup time. The slot row stays locked until the
+// surrounding transaction finishes, so concurrent checkouts can't overbook it.
+func Reserve(tx *gorm.DB, standID, slotID uint, date time.Time) (time.Time, error) {
+	var slot models.PickupSlot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND stand_id = ? AND is_active = ?", slotID, standID, true).
+		First(&slot).Error; err != nil {
+		return time.Time{}, ErrSlotNotFound
+	}
+
+	loc := settings.GetLocation(tx)
+	scheduledFor, err := SlotTime(slot, date, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now().In(loc)
+	if !scheduledFor.After(now) {
+		return time.Time{}, ErrSlotClosed
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if scheduledFor.After(today.AddDate(0, 0, MaxDaysAhead(tx)+1)) {
+		return time.Time{}, ErrTooFarAhead
+	}
+
+	booked, err := countBooked(tx, slot.ID, scheduledFor)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if booked >= int64(slot.Capacity) {
+		return time.Time{}, ErrSlotFull
+	}
+
+	return scheduledFor, nil
+}
+
+// Slots returns the stand's active slots with their remaining capacity on the date
+func Slots(db *gorm.DB, standID uint, date time.Time) ([]Availability, error) {
+	var slots []models.PickupSlot
+	if err := db.Where("stand_id = ? AND is_active = ?", standID, true).Order("start_time ASC").Find(&slots).Error; err != nil {
+		return nil, err
+	}
+
+	loc := settings.GetLocation(db)
+	now := time.Now()
+	result := make([]Availability, 0, len(slots))
+	for _, slot := range slots {
+		scheduledFor, err := SlotTime(slot, date, loc)
+		if err != nil {
+			return nil, fmt.Errorf("slot %d: %w", slot.ID, err)
+		}
+		booked, err := countBooked(db, slot.ID, scheduledFor)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := int64(slot.Capacity) - booked
+		if remaining < 0 {
+			remaining = 0
+		}
+		result = append(result, Availability{
+			PickupSlot:   slot,
+			ScheduledFor: scheduledFor,
+			Booked:       booked,
+			Remaining:    remaining,
+			Open:         scheduledFor.After(now),
+		})
+	}
+	return result, nil
+}
+
+// CheckCancel returns ErrCancelCutoff when a pre-order is too close to its slot
+// to be cancelled by the student. Immediate orders are not affected.
+func CheckCancel(db *gorm.DB, order models.Order) error {
+	if order.ScheduledFor == nil {
+		return nil
+	}
+	cutoff := time.Duration(settings.GetFloat(db, "preorder_cancel_cutoff_minutes", DefaultCancelCutoffMinutes)) * time.Minute
+	if time.Now().After(order.ScheduledFor.Add(-cutoff)) {
+		return ErrCancelCutoff
+	}
+	return nil
+}
+
+// countBooked counts the non-cancelled orders of a slot at the given time
+func countBooked(db *gorm.DB, slotID uint, scheduledFor time.Time) (int64, error) {
+	var count int64
+	err := db.Model(&models.Order{}).
+		Where("pickup_slot_id = ? AND scheduled_for = ? AND status <> ?", slotID, scheduledFor, "cancelled").
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,100 @@
+package receipt
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// htmlTemplate is escaped by html/template, so product names, options and
+// notes entered by users can't inject markup
+var htmlTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"payment": paymentLabel,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="utf-8">
+    <title>Receipt - {{.OrderNumber}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; max-width: 400px; margin: 0 auto; padding: 20px; }
+        .header { text-align: center; border-bottom: 2px solid #000; padding-bottom: 10px; margin-bottom: 20px; }
+        .header p { margin: 2px 0; }
+        .order-info { margin-bottom: 20px; }
+        .items { margin-bottom: 20px; }
+        .item { display: flex; justify-content: space-between; margin-bottom: 5px; }
+        .item-detail { font-size: 12px; color: #444; margin: -3px 0 5px 10px; }
+        .total { border-top: 1px solid #000; padding-top: 10px; }
+        .total .grand { font-weight: bold; }
+        .footer { text-align: center; margin-top: 20px; font-size: 12px; color: #666; }
+        @media print { body { margin: 0; } }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h2>{{.StoreName}}</h2>
+        <p>{{.SchoolName}}</p>
+        <p>Order Receipt</p>
+    </div>
+
+    <div class="order-info">
+        <p><strong>Order Number:</strong> {{.OrderNumber}}</p>
+        {{- if .QueueNumber}}
+        <p><strong>Queue Number:</strong> {{.QueueNumber}}</p>
+        {{- end}}
+        <p><strong>Customer:</strong> {{.CustomerName}}</p>
+        <p><strong>Date:</strong> {{.Date.Format "2006-01-02 15:04:05"}}</p>
+        <p><strong>Payment Method:</strong> {{payment .PaymentMethod}}</p>
+        <p><strong>Status:</strong> {{.Status}}</p>
+    </div>
+
+    <div class="items">
+        <h3>Items:</h3>
+        {{- range .Lines}}
+        <div class="item">
+            <span>{{.Name}} (x{{.Quantity}})</span>
+            <span>{{$.Money .Subtotal}}</span>
+        </div>
+        {{- range .Details}}
+        <div class="item-detail">{{.}}</div>
+        {{- end}}
+        {{- end}}
+    </div>
+
+    <div class="total">
+        <div class="item grand">
+            <span>Total Amount:</span>
+            <span>{{.Money .TotalAmount}}</span>
+        </div>
+        {{- if .CashAmount}}
+        <div class="item">
+            <span>Cash:</span>
+            <span>{{.Money .CashAmount}}</span>
+        </div>
+        <div class="item">
+            <span>Change:</span>
+            <span>{{.Money .Change}}</span>
+        </div>
+        {{- end}}
+        {{- if .PendingRefund}}
+        <div class="item">
+            <span>Refund Due:</span>
+            <span>{{.Money .PendingRefund}}</span>
+        </div>
+        {{- end}}
+    </div>
+
+    <div class="footer">
+        <p>Thank you for your order!</p>
+        <p>Generated on {{.PrintedAt.Format "2006-01-02 15:04:05"}}</p>
+    </div>
+</body>
+</html>
+`))
+
+// HTML renders the receipt as a printable HTML page
+func (r *Receipt) HTML() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
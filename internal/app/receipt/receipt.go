@@ -0,0 +1,230 @@
+// Package receipt renders order receipts as HTML, PDF, plain text for 58mm
+// and 80mm paper, and ESC/POS byte streams for thermal printers.
+package receipt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"swipeup-admin-v2/internal/app/fulfilment"
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/options"
+	"swipeup-admin-v2/internal/app/settings"
+
+	"gorm.io/gorm"
+)
+
+// Output formats
+const (
+	HTML   = "html"
+	PDF    = "pdf"
+	Text   = "text"
+	ESCPOS = "escpos"
+)
+
+// Paper widths in millimetres and the characters a thermal printer fits per line
+var columns = map[int]int{
+	58: 32,
+	80: 48,
+}
+
+var (
+	// ErrUnknownFormat is returned for a format other than html, pdf, text or escpos
+	ErrUnknownFormat = errors.New("format must be html, pdf, text or escpos")
+	// ErrUnknownWidth is returned for a paper width other than 58 or 80
+	ErrUnknownWidth = errors.New("width must be 58 or 80")
+)
+
+// Line is one order item on the receipt
+type Line struct {
+	Name      string
+	Quantity  int
+	UnitPrice float64
+	Subtotal  float64
+	Details   []string // Options, note and changes made by the stand
+}
+
+// Receipt holds everything printed on an order receipt
+type Receipt struct {
+	SchoolName    string
+	StoreName     string
+	Currency      string
+	OrderNumber   string
+	QueueNumber   string
+	CustomerName  string
+	Date          time.Time
+	PaymentMethod string
+	Status        string
+	Lines         []Line
+	TotalAmount   float64
+	CashAmount    float64
+	Change        float64
+	PendingRefund float64
+	PrintedAt     time.Time
+}
+
+// Build loads an order with its items, the stand's store name and the school
+// settings. Callers check that the requester may see the order.
+func Build(db *gorm.DB, orderID uint) (*Receipt, error) {
+	var order models.Order
+	if err := db.Preload("OrderItems.Product").
+		Preload("OrderItems.Options").
+		Preload("OrderItems.SubstituteProduct").
+		Preload("User").
+		Preload("Stand").
+		First(&order, orderID).Error; err != nil {
+		return nil, err
+	}
+
+	// Fall back to the stand account name when the stand has no settings yet
+	storeName := order.Stand.Name
+	var standSettings models.StandSettings
+	if err := db.Where("stand_id = ?", order.StandID).First(&standSettings).Error; err == nil && standSettings.StoreName != "" {
+		storeName = standSettings.StoreName
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	loc := settings.GetLocation(db)
+	r := &Receipt{
+		SchoolName:    settings.GetString(db, "school_name", "Swipeup School"),
+		StoreName:     storeName,
+		Currency:      settings.GetString(db, "currency", "IDR"),
+		OrderNumber:   order.OrderNumber,
+		QueueNumber:   order.QueueNumber,
+		CustomerName:  order.User.Name,
+		Date:          order.CreatedAt.In(loc),
+		PaymentMethod: order.PaymentMethod,
+		Status:        order.Status,
+		TotalAmount:   order.TotalAmount,
+		PendingRefund: order.PendingRefund,
+		PrintedAt:     time.Now().In(loc),
+	}
+	if order.PaymentMethod == "cash" && order.CashAmount > 0 {
+		r.CashAmount = order.CashAmount
+		r.Change = math.Max(order.CashAmount-order.TotalAmount, 0)
+	}
+
+	for _, item := range order.OrderItems {
+		line := Line{
+			Name:      item.Product.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.Price,
+			Subtotal:  item.Subtotal,
+		}
+		if len(item.Options) > 0 {
+			line.Details = append(line.Details, options.Describe(item.Options))
+		}
+		if item.Note != "" {
+			line.Details = append(line.Details, "Note: "+item.Note)
+		}
+		line.Details = append(line.Details, r.change(item)...)
+		r.Lines = append(r.Lines, line)
+	}
+
+	return r, nil
+}
+
+// change describes what the stand changed on an item
+func (r *Receipt) change(item models.OrderItem) []string {
+	var details []string
+	switch item.FulfilmentStatus {
+	case fulfilment.Unavailable:
+		details = append(details, fmt.Sprintf("Unavailable, was %s", r.Money(item.OriginalSubtotal)))
+	case fulfilment.PartiallyUnavailable:
+		details = append(details, fmt.Sprintf("%d unavailable, was %s", item.UnavailableQuantity, r.Money(item.OriginalSubtotal)))
+	case fulfilment.Substituted:
+		name := "another product"
+		if item.SubstituteProduct != nil {
+			name = item.SubstituteProduct.Name
+		}
+		details = append(details, fmt.Sprintf("Replaced by %s, was %s", name, r.Money(item.OriginalSubtotal)))
+	}
+	if item.FulfilmentNote != "" {
+		details = append(details, item.FulfilmentNote)
+	}
+	return details
+}
+
+// Money formats an amount in the school currency. Rupiah has no minor unit
+// and groups thousands with dots; other currencies use two decimals.
+func (r *Receipt) Money(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	if r.Currency == "IDR" || r.Currency == "" {
+		return sign + "Rp " + group(int64(math.Round(amount)), ".")
+	}
+	cents := int64(math.Round(amount * 100))
+	return fmt.Sprintf("%s%s %s.%02d", sign, r.Currency, group(cents/100, ","), cents%100)
+}
+
+// Render produces the receipt in a format and returns its content type.
+// Width only applies to text, escpos and pdf output.
+func (r *Receipt) Render(format string, width int) (string, []byte, error) {
+	switch format {
+	case HTML:
+	case PDF, Text, ESCPOS:
+		if _, ok := columns[width]; !ok {
+			return "", nil, ErrUnknownWidth
+		}
+	default:
+		return "", nil, ErrUnknownFormat
+	}
+
+	switch format {
+	case HTML:
+		body, err := r.HTML()
+		return "text/html; charset=utf-8", body, err
+	case PDF:
+		return "application/pdf", r.PDF(width), nil
+	case Text:
+		return "text/plain; charset=utf-8", []byte(r.Text(width)), nil
+	default:
+		return "application/octet-stream", r.ESCPOS(width), nil
+	}
+}
+
+// Filename is the download name for binary formats, empty for formats shown
+// in the browser
+func Filename(orderNumber, format string) string {
+	switch format {
+	case PDF:
+		return "receipt-" + orderNumber + ".pdf"
+	case ESCPOS:
+		return "receipt-" + orderNumber + ".bin"
+	}
+	return ""
+}
+
+// group inserts a separator between each group of three digits
+func group(n int64, separator string) string {
+	digits := fmt.Sprintf("%d", n)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(separator)
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
+
+// paymentLabel turns a payment method into the printed label
+func paymentLabel(method string) string {
+	switch method {
+	case "card":
+		return "Card"
+	case "cash":
+		return "Cash"
+	case "qris":
+		return "QRIS"
+	}
+	return method
+}
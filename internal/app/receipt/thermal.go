@@ -0,0 +1,250 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"swipeup-admin-v2/internal/app/pdf"
+)
+
+// ESC/POS commands
+var (
+	escInit  = []byte{0x1b, 0x40}             // ESC @: reset the printer
+	escAlign = []byte{0x1b, 0x61}             // ESC a n: 0 left, 1 centre
+	escBold  = []byte{0x1b, 0x45}             // ESC E n: 0 off, 1 on
+	escSize  = []byte{0x1d, 0x21}             // GS ! n: 0x00 normal, 0x11 double width and height
+	escFeed  = []byte{0x1b, 0x64, 0x04}       // ESC d 4: feed four lines
+	escCut   = []byte{0x1d, 0x56, 0x42, 0x00} // GS V B 0: feed to the cutter and cut partially
+)
+
+// escMode writes a command followed by its one-byte argument
+func escMode(buf *bytes.Buffer, command []byte, n byte) {
+	buf.Write(command)
+	buf.WriteByte(n)
+}
+
+// row is one printed line of a thermal receipt
+type row struct {
+	text   string
+	center bool
+	bold   bool
+	large  bool // Double width and height, so it fits half the columns
+}
+
+// layout lays the receipt out for a paper width in characters
+func (r *Receipt) layout(cols int) []row {
+	rule := row{text: strings.Repeat("-", cols)}
+	var rows []row
+
+	for _, text := range wrap(r.StoreName, cols) {
+		rows = append(rows, row{text: text, center: true, bold: true})
+	}
+	for _, text := range wrap(r.SchoolName, cols) {
+		rows = append(rows, row{text: text, center: true})
+	}
+	rows = append(rows, rule)
+
+	if r.QueueNumber != "" {
+		rows = append(rows, row{text: r.QueueNumber, center: true, bold: true, large: true})
+	}
+	rows = append(rows,
+		row{text: pair("Order", r.OrderNumber, cols)},
+		row{text: pair("Date", r.Date.Format("2006-01-02 15:04"), cols)},
+	)
+	if r.CustomerName != "" {
+		rows = append(rows, row{text: pair("Customer", r.CustomerName, cols)})
+	}
+	rows = append(rows,
+		row{text: pair("Payment", paymentLabel(r.PaymentMethod), cols)},
+		row{text: pair("Status", r.Status, cols)},
+		rule,
+	)
+
+	for _, line := range r.Lines {
+		// Long names continue below so the amount column stays clear
+		amount := r.Money(line.Subtotal)
+		names := wrap(fmt.Sprintf("%dx %s", line.Quantity, line.Name), cols-utf8.RuneCountInString(amount)-1)
+		if len(names) == 0 {
+			names = []string{""}
+		}
+		rows = append(rows, row{text: pair(names[0], amount, cols)})
+		for _, text := range names[1:] {
+			rows = append(rows, row{text: "   " + text})
+		}
+		for _, detail := range line.Details {
+			for _, text := range wrap(detail, cols-2) {
+				rows = append(rows, row{text: "  " + text})
+			}
+		}
+	}
+	rows = append(rows, rule, row{text: pair("TOTAL", r.Money(r.TotalAmount), cols), bold: true})
+
+	if r.CashAmount > 0 {
+		rows = append(rows,
+			row{text: pair("Cash", r.Money(r.CashAmount), cols)},
+			row{text: pair("Change", r.Money(r.Change), cols)},
+		)
+	}
+	if r.PendingRefund > 0 {
+		rows = append(rows, row{text: pair("Refund due", r.Money(r.PendingRefund), cols)})
+	}
+
+	rows = append(rows,
+		rule,
+		row{text: "Thank you for your order!", center: true},
+		row{text: r.PrintedAt.Format("2006-01-02 15:04:05"), center: true},
+	)
+	return rows
+}
+
+// Text renders the receipt as plain text for 58mm or 80mm paper
+func (r *Receipt) Text(width int) string {
+	cols := columns[width]
+	var b strings.Builder
+	for _, line := range r.layout(cols) {
+		text := line.text
+		if line.center {
+			text = strings.Repeat(" ", (cols-utf8.RuneCountInString(text))/2) + text
+		}
+		b.WriteString(strings.TrimRight(text, " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ESCPOS renders the receipt as a byte stream for ESC/POS thermal printers,
+// ending with a paper cut
+func (r *Receipt) ESCPOS(width int) []byte {
+	var buf bytes.Buffer
+	buf.Write(escInit)
+
+	for _, line := range r.layout(columns[width]) {
+		var align, bold, size byte
+		if line.center {
+			align = 1
+		}
+		if line.bold {
+			bold = 1
+		}
+		if line.large {
+			size = 0x11
+		}
+		escMode(&buf, escAlign, align)
+		escMode(&buf, escBold, bold)
+		escMode(&buf, escSize, size)
+		buf.WriteString(ascii(line.text))
+		buf.WriteByte('\n')
+	}
+
+	escMode(&buf, escAlign, 0)
+	escMode(&buf, escBold, 0)
+	escMode(&buf, escSize, 0)
+	buf.Write(escFeed)
+	buf.Write(escCut)
+	return buf.Bytes()
+}
+
+// PDF renders the receipt on a single page as wide as the thermal paper
+func (r *Receipt) PDF(width int) []byte {
+	cols := columns[width]
+	margin := 4 * pdf.MM
+	pageWidth := float64(width) * pdf.MM
+
+	// Size the monospaced font so a full row fills the printable width
+	size := (pageWidth - 2*margin) / (float64(cols) * 0.6)
+	lineHeight := size * 1.3
+
+	rows := r.layout(cols)
+	height := 2*margin + lineHeight
+	for _, line := range rows {
+		if line.large {
+			height += lineHeight * 2
+		} else {
+			height += lineHeight
+		}
+	}
+
+	doc := pdf.New(pageWidth, height)
+	doc.AddPage()
+	y := margin + lineHeight
+	for _, line := range rows {
+		// Bold only suits centred rows; aligned columns need the fixed-width font
+		font, fontSize := pdf.Courier, size
+		if line.bold && line.center {
+			font = pdf.HelveticaBold
+		}
+		if line.large {
+			fontSize = size * 2
+			y += lineHeight
+		}
+
+		x := margin
+		if line.center {
+			x = (pageWidth - pdf.TextWidth(font, fontSize, line.text)) / 2
+		}
+		doc.Text(x, y, font, fontSize, line.text)
+		y += lineHeight
+	}
+
+	return doc.Bytes()
+}
+
+// pair puts left and right at either end of a row. When both don't fit, the
+// left text is cut to leave room for the right.
+func pair(left, right string, cols int) string {
+	room := cols - utf8.RuneCountInString(right) - 1
+	if room < 1 {
+		return right
+	}
+	leftRunes := []rune(left)
+	if len(leftRunes) > room {
+		leftRunes = leftRunes[:room]
+	}
+	return string(leftRunes) + strings.Repeat(" ", cols-len(leftRunes)-utf8.RuneCountInString(right)) + right
+}
+
+// wrap breaks text into lines of at most width characters at spaces, splitting
+// words that are longer than a line
+func wrap(text string, width int) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		for utf8.RuneCountInString(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// ascii replaces characters the printer's default code page can't show
+func ascii(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r < 32 || r > 126 {
+			b.WriteByte('?')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,280 @@
+package reconcile
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/wallet"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tolerance absorbs float rounding when comparing money values
+const tolerance = 0.005
+
+// Issue kinds reported by the checker
+const (
+	IssueDrift          = "drift"           // User.Balance differs from the ledger
+	IssueChainBreak     = "chain_break"     // BalanceBefore differs from the previous row's BalanceAfter
+	IssueRowMismatch    = "row_mismatch"    // BalanceAfter differs from BalanceBefore plus the amount
+	IssueOpeningBalance = "opening_balance" // First row does not start from zero
+	IssueOrphanUser     = "orphan_user"     // Transaction points to a user that does not exist
+	IssueOrphanOrder    = "orphan_order"    // Transaction points to an order that does not exist
+)
+
+// Issue describes a single integrity problem
+type Issue struct {
+	Kind          string  `json:"kind"`
+	UserID        uint    `json:"user_id"`
+	TransactionID *uint   `json:"transaction_id,omitempty"`
+	Expected      float64 `json:"expected"`
+	Actual        float64 `json:"actual"`
+	Detail        string  `json:"detail"`
+}
+
+// WalletReport is the reconciliation result for one user
+type WalletReport struct {
+	UserID           uint    `json:"user_id"`
+	Name             string  `json:"name"`
+	Balance          float64 `json:"balance"`        // Stored User.Balance
+	LedgerBalance    float64 `json:"ledger_balance"` // Sum of signed transaction amounts
+	Drift            float64 `json:"drift"`          // Balance - LedgerBalance
+	TransactionCount int     `json:"transaction_count"`
+	Issues           []Issue `json:"issues"`
+}
+
+// Report is the result of a full reconciliation run
+type Report struct {
+	CheckedAt         time.Time            `json:"checked_at"`
+	WalletsChecked    int                  `json:"wallets_checked"`
+	WalletsWithIssues int                  `json:"wallets_with_issues"`
+	TotalDrift        float64              `json:"total_drift"`
+	Wallets           []WalletReport       `json:"wallets"` // Only wallets with issues
+	Orphans           []Issue              `json:"orphans"`
+	Repairs           []models.Transaction `json:"repairs,omitempty"`
+}
+
+// Options controls which wallets are checked and whether drift is repaired
+type Options struct {
+	UserIDs []uint // Empty means every user
+	Repair  bool
+	Reason  string // Required when Repair is set, recorded on the adjustment entry
+}
+
+// Run walks every wallet and reports drift, chain breaks and orphaned transactions.
+// With Repair set, each drifting wallet gets a correcting adjustment entry so the
+// ledger sums to the stored balance.
+func Run(db *gorm.DB, opts Options) (*Report, error) {
+	if opts.Repair && opts.Reason == "" {
+		return nil, fmt.Errorf("a reason is required to repair wallets")
+	}
+
+	report := &Report{CheckedAt: time.Now(), Wallets: []WalletReport{}, Orphans: []Issue{}}
+
+	query := db.Unscoped().Model(&models.User{}).Order("id ASC")
+	if len(opts.UserIDs) > 0 {
+		query = query.Where("id IN ?", opts.UserIDs)
+	}
+
+	var users []models.User
+	err := query.FindInBatches(&users, 200, func(batch *gorm.DB, _ int) error {
+		for _, user := range users {
+			walletReport, err := checkWallet(db, user)
+			if err != nil {
+				return err
+			}
+			report.WalletsChecked++
+			if len(walletReport.Issues) == 0 {
+				continue
+			}
+
+			report.WalletsWithIssues++
+			report.TotalDrift += walletReport.Drift
+			report.Wallets = append(report.Wallets, *walletReport)
+
+			if opts.Repair && math.Abs(walletReport.Drift) > tolerance {
+				repair, err := repairWallet(db, user.ID, opts.Reason)
+				if err != nil {
+					return err
+				}
+				if repair != nil {
+					report.Repairs = append(report.Repairs, *repair)
+				}
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	orphans, err := findOrphans(db)
+	if err != nil {
+		return nil, err
+	}
+	report.Orphans = orphans
+
+	return report, nil
+}
+
+// checkWallet verifies a single user's ledger chain against the stored balance
+func checkWallet(db *gorm.DB, user models.User) (*WalletReport, error) {
+	var transactions []models.Transaction
+	if err := db.Where("user_id = ?", user.ID).Order("created_at ASC, id ASC").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	report := &WalletReport{
+		UserID:           user.ID,
+		Name:             user.Name,
+		Balance:          user.Balance,
+		TransactionCount: len(transactions),
+		Issues:           []Issue{},
+	}
+
+	for i, t := range transactions {
+		transactionID := t.ID
+		signed := wallet.SignedAmount(t)
+		report.LedgerBalance += signed
+
+		if i == 0 && math.Abs(t.BalanceBefore) > tolerance {
+			report.Issues = append(report.Issues, Issue{
+				Kind:          IssueOpeningBalance,
+				UserID:        user.ID,
+				TransactionID: &transactionID,
+				Expected:      0,
+				Actual:        t.BalanceBefore,
+				Detail:        "First transaction " + t.TransactionNumber + " starts from a balance with no ledger entry",
+			})
+		}
+
+		if i > 0 {
+			previous := transactions[i-1]
+			if math.Abs(t.BalanceBefore-previous.BalanceAfter) > tolerance {
+				report.Issues = append(report.Issues, Issue{
+					Kind:          IssueChainBreak,
+					UserID:        user.ID,
+					TransactionID: &transactionID,
+					Expected:      previous.BalanceAfter,
+					Actual:        t.BalanceBefore,
+					Detail:        fmt.Sprintf("%s does not continue from %s", t.TransactionNumber, previous.TransactionNumber),
+				})
+			}
+		}
+
+		if math.Abs(t.BalanceBefore+signed-t.BalanceAfter) > tolerance {
+			report.Issues = append(report.Issues, Issue{
+				Kind:          IssueRowMismatch,
+				UserID:        user.ID,
+				TransactionID: &transactionID,
+				Expected:      t.BalanceBefore + signed,
+				Actual:        t.BalanceAfter,
+				Detail:        t.TransactionNumber + " balance_after does not match balance_before and amount",
+			})
+		}
+	}
+
+	report.Drift = user.Balance - report.LedgerBalance
+	if math.Abs(report.Drift) > tolerance {
+		report.Issues = append(report.Issues, Issue{
+			Kind:     IssueDrift,
+			UserID:   user.ID,
+			Expected: report.LedgerBalance,
+			Actual:   user.Balance,
+			Detail:   "Stored balance differs from the sum of transactions",
+		})
+	}
+
+	return report, nil
+}
+
+// repairWallet posts a ledger-only adjustment that makes the ledger sum to the
+// stored balance. The stored balance itself is left untouched.
+func repairWallet(db *gorm.DB, userID uint, reason string) (*models.Transaction, error) {
+	var repair *models.Transaction
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		var transactions []models.Transaction
+		if err := tx.Where("user_id = ?", userID).Find(&transactions).Error; err != nil {
+			return err
+		}
+
+		ledger := 0.0
+		for _, t := range transactions {
+			ledger += wallet.SignedAmount(t)
+		}
+
+		drift := user.Balance - ledger
+		if math.Abs(drift) <= tolerance {
+			return nil
+		}
+
+		transactionNumber, err := numbering.Next(tx, numbering.Adjustment, 0)
+		if err != nil {
+			return err
+		}
+
+		repair = &models.Transaction{
+			TransactionNumber: transactionNumber,
+			UserID:            userID,
+			Type:              "adjustment",
+			Amount:            drift,
+			BalanceBefore:     user.Balance - drift,
+			BalanceAfter:      user.Balance,
+			Description:       "Reconciliation adjustment: " + reason,
+		}
+		return tx.Create(repair).Error
+	})
+
+	return repair, err
+}
+
+// findOrphans returns transactions pointing to users or orders that do not exist
+func findOrphans(db *gorm.DB) ([]Issue, error) {
+	orphans := []Issue{}
+
+	var missingUser []models.Transaction
+	if err := db.Joins("LEFT JOIN users ON users.id = transactions.user_id").
+		Where("users.id IS NULL").
+		Find(&missingUser).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range missingUser {
+		transactionID := t.ID
+		orphans = append(orphans, Issue{
+			Kind:          IssueOrphanUser,
+			UserID:        t.UserID,
+			TransactionID: &transactionID,
+			Actual:        t.Amount,
+			Detail:        t.TransactionNumber + " belongs to a user that does not exist",
+		})
+	}
+
+	var missingOrder []models.Transaction
+	if err := db.Joins("LEFT JOIN orders ON orders.id = transactions.order_id").
+		Where("transactions.order_id IS NOT NULL AND orders.id IS NULL").
+		Find(&missingOrder).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range missingOrder {
+		transactionID := t.ID
+		orphans = append(orphans, Issue{
+			Kind:          IssueOrphanOrder,
+			UserID:        t.UserID,
+			TransactionID: &transactionID,
+			Actual:        t.Amount,
+			Detail:        fmt.Sprintf("%s references order %d that does not exist", t.TransactionNumber, *t.OrderID),
+		})
+	}
+
+	return orphans, nil
+}
@@ -0,0 +1,256 @@
+// Package reporting summarises orders over any date range, bucketed by day,
+// week, month or year. Ranges and buckets follow the school time zone, so
+// results don't depend on the server's zone or the database connection.
+package reporting
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/orderstatus"
+
+	"gorm.io/gorm"
+)
+
+// Granularities
+const (
+	Day   = "day"
+	Week  = "week" // Monday to Sunday
+	Month = "month"
+	Year  = "year"
+)
+
+// MaxBuckets caps a report, e.g. a little over three years by day
+const MaxBuckets = 1100
+
+var (
+	// ErrInvalidRange is returned for unparsable dates or a range that ends before it starts
+	ErrInvalidRange = errors.New("invalid range, use from and to as YYYY-MM-DD or year and month")
+	// ErrInvalidGranularity is returned for a granularity other than day, week, month or year
+	ErrInvalidGranularity = errors.New("granularity must be day, week, month or year")
+	// ErrTooManyBuckets is returned when a range is too long for its granularity
+	ErrTooManyBuckets = fmt.Errorf("range has more than %d periods, use a larger granularity", MaxBuckets)
+)
+
+// Range is a half-open period [From, To) in the school time zone
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+// MonthRange returns the whole calendar month in loc
+func MonthRange(year int, month time.Month, loc *time.Location) Range {
+	from := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	return Range{From: from, To: from.AddDate(0, 1, 0)}
+}
+
+// YearRange returns the whole calendar year in loc
+func YearRange(year int, loc *time.Location) Range {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	return Range{From: from, To: from.AddDate(1, 0, 0)}
+}
+
+// ParseRange reads a range from query parameters: from and to as YYYY-MM-DD,
+// both days included, or year with an optional month. Without any of them
+// it is the current month.
+func ParseRange(values url.Values, loc *time.Location, now time.Time) (Range, error) {
+	from, to := values.Get("from"), values.Get("to")
+	if from != "" || to != "" {
+		if from == "" || to == "" {
+			return Range{}, ErrInvalidRange
+		}
+		start, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			return Range{}, ErrInvalidRange
+		}
+		end, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil || end.Before(start) {
+			return Range{}, ErrInvalidRange
+		}
+		return Range{From: start, To: end.AddDate(0, 0, 1)}, nil
+	}
+
+	if value := values.Get("year"); value != "" {
+		year, err := strconv.Atoi(value)
+		if err != nil || year < 1 {
+			return Range{}, ErrInvalidRange
+		}
+		if value := values.Get("month"); value != "" {
+			month, err := strconv.Atoi(value)
+			if err != nil || month < 1 || month > 12 {
+				return Range{}, ErrInvalidRange
+			}
+			return MonthRange(year, time.Month(month), loc), nil
+		}
+		return YearRange(year, loc), nil
+	}
+
+	now = now.In(loc)
+	return MonthRange(now.Year(), now.Month(), loc), nil
+}
+
+// ParseGranularity validates a granularity, using def when value is empty
+func ParseGranularity(value, def string) (string, error) {
+	if value == "" {
+		value = def
+	}
+	switch value {
+	case Day, Week, Month, Year:
+		return value, nil
+	}
+	return "", ErrInvalidGranularity
+}
+
+// Start returns the start of the period containing t, in t's location
+func Start(t time.Time, granularity string) time.Time {
+	year, month, day := t.Date()
+	switch granularity {
+	case Week:
+		offset := (int(t.Weekday()) + 6) % 7 // Days since Monday
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case Month:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	case Year:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// next returns the start of the period after the one starting at start
+func next(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	case Year:
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Label names the period starting at start: 2026-10-18, 2026-W42, 2026-10 or 2026
+func Label(start time.Time, granularity string) string {
+	switch granularity {
+	case Week:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case Month:
+		return start.Format("2006-01")
+	case Year:
+		return start.Format("2006")
+	}
+	return start.Format("2006-01-02")
+}
+
+// Totals are order counts and amounts for a period
+type Totals struct {
+	Orders    int64   `json:"total_orders"`
+	Completed int64   `json:"completed_orders"`
+	Pending   int64   `json:"pending_orders"` // Not yet done or cancelled
+	Cancelled int64   `json:"cancelled_orders"`
+	Amount    float64 `json:"total_amount"` // Orders that weren't cancelled
+}
+
+func (t *Totals) add(status string, amount float64) {
+	t.Orders++
+	switch status {
+	case orderstatus.Done:
+		t.Completed++
+	case orderstatus.Cancelled:
+		t.Cancelled++
+	default:
+		t.Pending++
+	}
+	if status != orderstatus.Cancelled {
+		t.Amount += amount
+	}
+}
+
+// Bucket is one period of a report, clipped to the report range
+type Bucket struct {
+	Label string    `json:"label"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"` // Exclusive
+	Totals
+}
+
+// Report is an order summary over a range
+type Report struct {
+	Timezone    string    `json:"timezone"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"` // Exclusive
+	Granularity string    `json:"granularity"`
+	Buckets     []Bucket  `json:"buckets"`
+	Summary     Totals    `json:"summary"`
+}
+
+// Buckets lists the periods of a range, the first and last clipped to it
+func Buckets(r Range, granularity string) ([]Bucket, error) {
+	var buckets []Bucket
+	for start := Start(r.From, granularity); start.Before(r.To); start = next(start, granularity) {
+		if len(buckets) == MaxBuckets {
+			return nil, ErrTooManyBuckets
+		}
+		bucket := Bucket{Label: Label(start, granularity), Start: start, End: next(start, granularity)}
+		if bucket.Start.Before(r.From) {
+			bucket.Start = r.From
+		}
+		if bucket.End.After(r.To) {
+			bucket.End = r.To
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+// Orders summarises the orders matched by query, e.g.
+// db.Where("stand_id = ?", standID), created in the range. Orders are
+// bucketed in Go by their time in the range's location.
+func Orders(query *gorm.DB, r Range, granularity string) (*Report, error) {
+	buckets, err := Buckets(r, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		CreatedAt   time.Time
+		TotalAmount float64
+		Status      string
+	}
+	if err := query.Model(&models.Order{}).
+		Select("created_at, total_amount, status").
+		Where("created_at >= ? AND created_at < ?", r.From, r.To).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(buckets))
+	for i, bucket := range buckets {
+		index[bucket.Label] = i
+	}
+
+	report := &Report{
+		Timezone:    r.From.Location().String(),
+		From:        r.From,
+		To:          r.To,
+		Granularity: granularity,
+		Buckets:     buckets,
+	}
+	loc := r.From.Location()
+	for _, row := range rows {
+		label := Label(Start(row.CreatedAt.In(loc), granularity), granularity)
+		if i, ok := index[label]; ok {
+			report.Buckets[i].add(row.Status, row.TotalAmount)
+		}
+		report.Summary.add(row.Status, row.TotalAmount)
+	}
+	if report.Buckets == nil {
+		report.Buckets = []Bucket{}
+	}
+	return report, nil
+}
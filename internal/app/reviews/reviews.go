@@ -0,0 +1,65 @@
+package reviews
+
+import (
+	"math"
+
+	"swipeup-admin-v2/internal/app/models"
+
+	"gorm.io/gorm"
+)
+
+// Rating bounds
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Stats is the average rating and number of visible reviews
+type Stats struct {
+	Average float64 `json:"rating_average"` // Rounded to one decimal, 0 without reviews
+	Count   int64   `json:"rating_count"`
+}
+
+// ValidRating reports whether rating is between MinRating and MaxRating
+func ValidRating(rating int) bool {
+	return rating >= MinRating && rating <= MaxRating
+}
+
+// ProductStats returns the rating stats of the given products. Hidden
+// reviews are not counted; products without reviews are missing from the map.
+func ProductStats(db *gorm.DB, productIDs []uint) (map[uint]Stats, error) {
+	return stats(db, "product_id", productIDs, "product_id IS NOT NULL")
+}
+
+// StandStats returns the rating stats of the given stands, from the stand
+// reviews only (not their products'). Hidden reviews are not counted.
+func StandStats(db *gorm.DB, standIDs []uint) (map[uint]Stats, error) {
+	return stats(db, "stand_id", standIDs, "order_item_id = 0")
+}
+
+// stats averages visible ratings grouped by column
+func stats(db *gorm.DB, column string, ids []uint, condition string) (map[uint]Stats, error) {
+	result := make(map[uint]Stats, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		ID      uint
+		Average float64
+		Count   int64
+	}
+	if err := db.Model(&models.Review{}).
+		Select(column+" AS id, AVG(rating) AS average, COUNT(*) AS count").
+		Where(column+" IN ? AND is_hidden = ?", ids, false).
+		Where(condition).
+		Group(column).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.ID] = Stats{Average: math.Round(row.Average*10) / 10, Count: row.Count}
+	}
+	return result, nil
+}
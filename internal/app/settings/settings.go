@@ -0,0 +1,46 @@
+package settings
+
+import (
+	"strconv"
+	"time"
+	_ "time/tzdata" // Time zone database for hosts without one
+
+	"swipeup-admin-v2/internal/app/models"
+
+	"gorm.io/gorm"
+)
+
+// GetString returns the value of an active global setting or the default value
+func GetString(db *gorm.DB, key, defaultValue string) string {
+	var setting models.GlobalSettings
+	if err := db.Where("`key` = ? AND is_active = ?", key, true).First(&setting).Error; err != nil {
+		return defaultValue
+	}
+	if setting.Value == "" {
+		return defaultValue
+	}
+	return setting.Value
+}
+
+// GetFloat returns a global setting parsed as float64 or the default value
+func GetFloat(db *gorm.DB, key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(GetString(db, key, ""), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// DefaultTimezone is used when school_timezone is missing or invalid
+const DefaultTimezone = "Asia/Jakarta"
+
+// GetLocation returns the school time zone from the school_timezone setting.
+// Day, month and year boundaries in reports and filters follow this zone
+// rather than the server's.
+func GetLocation(db *gorm.DB) *time.Location {
+	loc, err := time.LoadLocation(GetString(db, "school_timezone", DefaultTimezone))
+	if err != nil {
+		loc, _ = time.LoadLocation(DefaultTimezone)
+	}
+	return loc
+}
@@ -0,0 +1,200 @@
+package settlement
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/numbering"
+	"swipeup-admin-v2/internal/app/settings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNothingToSettle is returned when a period has no orders or refunds to settle
+var ErrNothingToSettle = errors.New("no unsettled orders or refunds in period")
+
+// ErrInvalidPeriod is returned when the period end is not after the previous settlement
+var ErrInvalidPeriod = errors.New("period end must be after the previous settlement period end")
+
+// Compute builds an unsaved settlement for all unsettled completed orders of a
+// stand up to periodEnd (exclusive) and the refunds made on those orders
+func Compute(db *gorm.DB, standID uint, periodEnd time.Time) (*models.Settlement, error) {
+	periodStart, err := nextPeriodStart(db, standID)
+	if err != nil {
+		return nil, err
+	}
+	if !periodStart.IsZero() && !periodEnd.After(periodStart) {
+		return nil, ErrInvalidPeriod
+	}
+
+	var orders []models.Order
+	if err := db.Where("stand_id = ? AND status = ? AND settlement_id IS NULL AND created_at < ?", standID, "done", periodEnd).
+		Order("created_at ASC").
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+
+	// Only refunds of the orders settled here count. Cancelled orders are never
+	// sales, so refunding them costs the stand nothing.
+	orderIDs := make([]uint, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	var refunds []models.Transaction
+	if len(orderIDs) > 0 {
+		if err := db.Where("order_id IN ? AND type = ? AND settlement_id IS NULL", orderIDs, "refund").
+			Order("created_at ASC").
+			Find(&refunds).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	// An item change lowers the order total and refunds the wallet for the
+	// difference, so the sale is counted at the total before those refunds and
+	// the refund lines bring it back down
+	refunded := make(map[uint]float64, len(refunds))
+	for _, refund := range refunds {
+		refunded[*refund.OrderID] += refund.Amount
+	}
+
+	settlement := &models.Settlement{
+		StandID:        standID,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodEnd,
+		CommissionRate: settings.GetFloat(db, "commission_rate", 0),
+	}
+
+	// Without a previous settlement the period starts at the oldest included entry
+	for _, order := range orders {
+		orderID := order.ID
+		amount := order.TotalAmount + refunded[order.ID]
+		switch order.PaymentMethod {
+		case "qris":
+			settlement.QRISSales += amount
+		case "cash":
+			settlement.CashSales += amount
+		default:
+			settlement.WalletSales += amount
+		}
+		settlement.OrderCount++
+		settlement.Lines = append(settlement.Lines, models.SettlementLine{
+			Type:          "sale",
+			OrderID:       &orderID,
+			Reference:     order.OrderNumber,
+			PaymentMethod: order.PaymentMethod,
+			Amount:        amount,
+			OccurredAt:    order.CreatedAt,
+		})
+		if periodStart.IsZero() && (settlement.PeriodStart.IsZero() || order.CreatedAt.Before(settlement.PeriodStart)) {
+			settlement.PeriodStart = order.CreatedAt
+		}
+	}
+
+	for _, refund := range refunds {
+		transactionID := refund.ID
+		settlement.Refunds += refund.Amount
+		settlement.Lines = append(settlement.Lines, models.SettlementLine{
+			Type:          "refund",
+			OrderID:       refund.OrderID,
+			TransactionID: &transactionID,
+			Reference:     refund.TransactionNumber,
+			PaymentMethod: "card",
+			Amount:        -refund.Amount,
+			OccurredAt:    refund.CreatedAt,
+		})
+		if periodStart.IsZero() && (settlement.PeriodStart.IsZero() || refund.CreatedAt.Before(settlement.PeriodStart)) {
+			settlement.PeriodStart = refund.CreatedAt
+		}
+	}
+
+	settlement.GrossSales = settlement.WalletSales + settlement.QRISSales + settlement.CashSales
+	settlement.Commission = math.Round((settlement.GrossSales - settlement.Refunds) * settlement.CommissionRate / 100)
+	settlement.NetRevenue = settlement.GrossSales - settlement.Refunds - settlement.Commission
+	settlement.PayoutAmount = settlement.WalletSales - settlement.Refunds - settlement.Commission
+
+	return settlement, nil
+}
+
+// Close computes and persists an immutable settlement, stamping every included
+// order and refund so it can never be settled twice.
+// It must be called inside a database transaction.
+func Close(tx *gorm.DB, standID uint, periodEnd time.Time, closedByID uint) (*models.Settlement, error) {
+	// Serialize concurrent closes for the same stand
+	var stand models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stand, standID).Error; err != nil {
+		return nil, err
+	}
+
+	settlement, err := Compute(tx, standID, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	if len(settlement.Lines) == 0 {
+		return nil, ErrNothingToSettle
+	}
+
+	settlement.SettlementNumber, err = numbering.Next(tx, numbering.Settlement, standID)
+	if err != nil {
+		return nil, err
+	}
+	settlement.ClosedByID = closedByID
+
+	if err := tx.Create(settlement).Error; err != nil {
+		return nil, err
+	}
+
+	var orderIDs, transactionIDs []uint
+	for _, line := range settlement.Lines {
+		if line.Type == "sale" {
+			orderIDs = append(orderIDs, *line.OrderID)
+		} else {
+			transactionIDs = append(transactionIDs, *line.TransactionID)
+		}
+	}
+
+	if len(orderIDs) > 0 {
+		if err := tx.Model(&models.Order{}).Where("id IN ?", orderIDs).Update("settlement_id", settlement.ID).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(transactionIDs) > 0 {
+		if err := tx.Model(&models.Transaction{}).Where("id IN ?", transactionIDs).Update("settlement_id", settlement.ID).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return settlement, nil
+}
+
+// nextPeriodStart returns the end of the stand's last closed settlement, or zero if none
+func nextPeriodStart(db *gorm.DB, standID uint) (time.Time, error) {
+	var last models.Settlement
+	err := db.Where("stand_id = ?", standID).Order("period_end DESC").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return last.PeriodEnd, nil
+}
+
+// ParsePeriodEnd parses a period end given as RFC3339 or as a date (YYYY-MM-DD).
+// A date closes the period at the end of that day. An empty value means now.
+func ParsePeriodEnd(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid period_end %q, use YYYY-MM-DD or RFC3339", value)
+	}
+	return day.AddDate(0, 0, 1), nil
+}
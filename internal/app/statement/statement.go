@@ -0,0 +1,197 @@
+package statement
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/pdf"
+	"swipeup-admin-v2/internal/app/wallet"
+
+	"gorm.io/gorm"
+)
+
+// Line is one transaction on a statement with the balance after it
+type Line struct {
+	Date              time.Time `json:"date"`
+	TransactionNumber string    `json:"transaction_number"`
+	Type              string    `json:"type"`
+	Description       string    `json:"description"`
+	Amount            float64   `json:"amount"` // Signed: credits positive, debits negative
+	RunningBalance    float64   `json:"running_balance"`
+}
+
+// Statement is a student's account statement for a period
+type Statement struct {
+	User           models.User `json:"user"`
+	From           time.Time   `json:"from"`
+	To             time.Time   `json:"to"` // Exclusive
+	OpeningBalance float64     `json:"opening_balance"`
+	TotalCredits   float64     `json:"total_credits"`
+	TotalDebits    float64     `json:"total_debits"`
+	ClosingBalance float64     `json:"closing_balance"`
+	Lines          []Line      `json:"lines"`
+}
+
+// Build loads a user's transactions in [from, to) and computes opening,
+// running and closing balances from the ledger
+func Build(db *gorm.DB, userID uint, from, to time.Time) (*Statement, error) {
+	st := &Statement{From: from, To: to, Lines: []Line{}}
+	if err := db.First(&st.User, userID).Error; err != nil {
+		return nil, err
+	}
+
+	// Opening balance is the balance after the last transaction before the period
+	var previous models.Transaction
+	hasPrevious := true
+	err := db.Where("user_id = ? AND created_at < ?", userID, from).Order("created_at DESC, id DESC").First(&previous).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		hasPrevious = false
+	} else if err != nil {
+		return nil, err
+	}
+	st.OpeningBalance = previous.BalanceAfter
+
+	var transactions []models.Transaction
+	if err := db.Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to).
+		Order("created_at ASC, id ASC").
+		Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	// Without earlier history, start from the first row's recorded balance
+	if !hasPrevious && len(transactions) > 0 {
+		st.OpeningBalance = transactions[0].BalanceBefore
+	}
+
+	running := st.OpeningBalance
+	for _, t := range transactions {
+		amount := wallet.SignedAmount(t)
+		running += amount
+		if amount >= 0 {
+			st.TotalCredits += amount
+		} else {
+			st.TotalDebits -= amount
+		}
+		st.Lines = append(st.Lines, Line{
+			Date:              t.CreatedAt.In(from.Location()), // Shown in the zone of the period
+			TransactionNumber: t.TransactionNumber,
+			Type:              t.Type,
+			Description:       t.Description,
+			Amount:            amount,
+			RunningBalance:    running,
+		})
+	}
+	st.ClosingBalance = running
+
+	return st, nil
+}
+
+// WriteCSV writes the statement as CSV with opening and closing balance rows
+func (st *Statement) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{
+		{"Account Statement", st.User.Name, st.User.StudentId, st.User.Class},
+		{"Period", st.From.Format("2006-01-02"), st.To.AddDate(0, 0, -1).Format("2006-01-02")},
+		{},
+		{"Date", "Transaction Number", "Type", "Description", "Amount", "Balance"},
+		{st.From.Format("2006-01-02"), "", "", "Opening balance", "", money(st.OpeningBalance)},
+	}
+	for _, line := range st.Lines {
+		rows = append(rows, []string{
+			line.Date.Format("2006-01-02 15:04:05"),
+			line.TransactionNumber,
+			line.Type,
+			line.Description,
+			money(line.Amount),
+			money(line.RunningBalance),
+		})
+	}
+	rows = append(rows,
+		[]string{st.To.AddDate(0, 0, -1).Format("2006-01-02"), "", "", "Closing balance", "", money(st.ClosingBalance)},
+		[]string{},
+		[]string{"Total credits", money(st.TotalCredits)},
+		[]string{"Total debits", money(st.TotalDebits)},
+	)
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// PDF renders the statement as an A4 PDF document
+func (st *Statement) PDF(schoolName string) []byte {
+	const (
+		margin     = 40.0
+		lineHeight = 14.0
+	)
+
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	right := doc.Width() - margin
+	y := 0.0
+
+	header := func() {
+		doc.AddPage()
+		y = margin + 10
+		doc.Text(margin, y, pdf.HelveticaBold, 14, schoolName+" - Account Statement")
+		y += lineHeight * 1.5
+		doc.Text(margin, y, pdf.Helvetica, 10, fmt.Sprintf("%s (%s) %s", st.User.Name, st.User.StudentId, st.User.Class))
+		y += lineHeight
+		doc.Text(margin, y, pdf.Helvetica, 10, fmt.Sprintf("Period: %s to %s",
+			st.From.Format("2006-01-02"), st.To.AddDate(0, 0, -1).Format("2006-01-02")))
+		y += lineHeight * 1.5
+		doc.Text(margin, y, pdf.HelveticaBold, 9, "Date")
+		doc.Text(margin+95, y, pdf.HelveticaBold, 9, "Number")
+		doc.Text(margin+215, y, pdf.HelveticaBold, 9, "Description")
+		doc.TextRight(right-80, y, pdf.HelveticaBold, 9, "Amount")
+		doc.TextRight(right, y, pdf.HelveticaBold, 9, "Balance")
+		y += 4
+		doc.Line(margin, y, right, y)
+		y += lineHeight
+	}
+
+	row := func(date, number, description, amount, balance string) {
+		if y > doc.Height()-margin {
+			header()
+		}
+		doc.Text(margin, y, pdf.Helvetica, 9, date)
+		doc.Text(margin+95, y, pdf.Helvetica, 9, number)
+		doc.Text(margin+215, y, pdf.Helvetica, 9, truncate(description, 38))
+		doc.TextRight(right-80, y, pdf.Helvetica, 9, amount)
+		doc.TextRight(right, y, pdf.Helvetica, 9, balance)
+		y += lineHeight
+	}
+
+	header()
+	row(st.From.Format("2006-01-02"), "", "Opening balance", "", money(st.OpeningBalance))
+	for _, line := range st.Lines {
+		row(line.Date.Format("2006-01-02 15:04"), line.TransactionNumber, line.Description, money(line.Amount), money(line.RunningBalance))
+	}
+	row(st.To.AddDate(0, 0, -1).Format("2006-01-02"), "", "Closing balance", "", money(st.ClosingBalance))
+
+	y += 4
+	doc.Line(margin, y, right, y)
+	y += lineHeight
+	doc.Text(margin, y, pdf.Helvetica, 10, "Total credits: "+money(st.TotalCredits))
+	y += lineHeight
+	doc.Text(margin, y, pdf.Helvetica, 10, "Total debits: "+money(st.TotalDebits))
+
+	return doc.Bytes()
+}
+
+func money(amount float64) string {
+	return fmt.Sprintf("%.0f", amount)
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= max {
+		return string(runes)
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,100 @@
+package wallet
+
+import (
+	"errors"
+
+	"swipeup-admin-v2/internal/app/models"
+	"swipeup-admin-v2/internal/app/notify"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientBalance is returned when a debit would make the balance negative
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// Entry describes a single balance movement on a user's wallet
+type Entry struct {
+	UserID            uint
+	TransactionNumber string
+	Type              string  // top_up, purchase, refund, adjustment, cash_out, transfer_in, transfer_out
+	Amount            float64 // Always positive, except for adjustments where the sign is the direction
+	Description       string
+	OrderID           *uint
+	CheckoutID        *uint // Multi-stand checkout debited as a whole
+}
+
+// Credit adds the entry amount to the user's balance and records the transaction.
+// It must be called inside a database transaction.
+func Credit(tx *gorm.DB, entry Entry) (*models.Transaction, error) {
+	return post(tx, entry, entry.Amount)
+}
+
+// Debit subtracts the entry amount from the user's balance and records the transaction.
+// It must be called inside a database transaction.
+func Debit(tx *gorm.DB, entry Entry) (*models.Transaction, error) {
+	return post(tx, entry, -entry.Amount)
+}
+
+// Adjust applies a signed correction to the user's balance as an adjustment entry.
+// It must be called inside a database transaction.
+func Adjust(tx *gorm.DB, entry Entry) (*models.Transaction, error) {
+	entry.Type = "adjustment"
+	return post(tx, entry, entry.Amount)
+}
+
+// debitTypes are ledger types that take money out of the wallet
+var debitTypes = map[string]bool{
+	"purchase":     true,
+	"cash_out":     true,
+	"transfer_out": true,
+}
+
+// SignedAmount returns the effect a ledger row has on the balance.
+// Purchases, cash-outs and outgoing transfers are debits; top-ups, refunds,
+// incoming transfers and (already signed) adjustments are credits.
+func SignedAmount(t models.Transaction) float64 {
+	if debitTypes[t.Type] {
+		return -t.Amount
+	}
+	return t.Amount
+}
+
+// post locks the user row, applies delta to the balance and writes the ledger row
+func post(tx *gorm.DB, entry Entry, delta float64) (*models.Transaction, error) {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, entry.UserID).Error; err != nil {
+		return nil, err
+	}
+
+	if user.Balance+delta < 0 {
+		return nil, ErrInsufficientBalance
+	}
+
+	transaction := models.Transaction{
+		TransactionNumber: entry.TransactionNumber,
+		UserID:            user.ID,
+		Type:              entry.Type,
+		Amount:            entry.Amount,
+		BalanceBefore:     user.Balance,
+		BalanceAfter:      user.Balance + delta,
+		Description:       entry.Description,
+		OrderID:           entry.OrderID,
+		CheckoutID:        entry.CheckoutID,
+	}
+
+	if err := tx.Model(&user).Update("balance", transaction.BalanceAfter).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(&transaction).Error; err != nil {
+		return nil, err
+	}
+
+	// Every balance change passes here, so this is where thresholds are checked
+	if err := notify.CheckTransaction(tx, transaction); err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
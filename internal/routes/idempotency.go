@@ -0,0 +1,221 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"time"
+
+	"swipeup-admin-v2/internal/app/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyKeyHeader is the request header clients use to make retries safe
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyKeyTTL is how long a stored response can be replayed
+const idempotencyKeyTTL = 24 * time.Hour
+
+// responseRecorder captures the response body so it can be stored for replay
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware replays the stored response when a request is retried with
+// the same Idempotency-Key and body. Reusing a key with a different body, or while
+// the first request is still running, returns 409 Conflict. Requests without the
+// header are processed normally. Must run after AuthMiddleware.
+func IdempotencyMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 100 characters"})
+			c.Abort()
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		// Read and restore the body so the handler can still bind it
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash := hashRequest(c.Request, body)
+
+		record := models.IdempotencyKey{
+			Key:         key,
+			UserID:      userID.(uint),
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: requestHash,
+			Status:      "processing",
+			ExpiresAt:   time.Now().Add(idempotencyKeyTTL),
+		}
+
+		// Drop an expired record for this key so it can be reused
+		db.Where("`key` = ? AND user_id = ? AND expires_at < ?", key, record.UserID, time.Now()).Delete(&models.IdempotencyKey{})
+
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store idempotency key"})
+			c.Abort()
+			return
+		}
+
+		if result.RowsAffected == 0 {
+			var existing models.IdempotencyKey
+			if err := db.Where("`key` = ? AND user_id = ?", key, record.UserID).First(&existing).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load idempotency key"})
+				c.Abort()
+				return
+			}
+
+			if existing.RequestHash != requestHash {
+				c.JSON(http.StatusConflict, gin.H{"error": "Idempotency-Key was already used with a different request"})
+				c.Abort()
+				return
+			}
+
+			if existing.Status != "completed" {
+				c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+				c.Abort()
+				return
+			}
+
+			// Replay the original response
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(existing.ResponseCode, existing.ResponseContentType, []byte(existing.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		// A panicking handler must not leave the key stuck in processing
+		defer func() {
+			if r := recover(); r != nil {
+				db.Delete(&record)
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		// Server errors are not cached so the client can retry with the same key
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			db.Delete(&record)
+			return
+		}
+
+		if err := db.Model(&record).Updates(map[string]interface{}{
+			"status":                "completed",
+			"response_code":         status,
+			"response_content_type": recorder.Header().Get("Content-Type"),
+			"response_body":         recorder.body.String(),
+		}).Error; err != nil {
+			// Without the stored response a retry would get 409 until the key expires
+			log.Printf("idempotency: failed to store response for key %q: %v", key, err)
+			db.Delete(&record)
+		}
+	}
+}
+
+// hashRequest hashes the method, path and body. Multipart bodies are hashed
+// by their fields and file contents, since clients pick a new boundary on
+// every retry.
+func hashRequest(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+
+	parts, ok := multipartParts(r.Header.Get("Content-Type"), body)
+	if ok {
+		encoded, _ := json.Marshal(parts)
+		hash.Write(encoded)
+	} else {
+		hash.Write(body)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// multipartParts returns the sorted name, kind and value of every part of a
+// multipart body, with files as the SHA-256 of their contents. It reports
+// false for other content types or a body that doesn't parse.
+func multipartParts(contentType string, body []byte) ([][3]string, bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, false
+	}
+
+	var parts [][3]string
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false
+		}
+
+		if part.FileName() != "" {
+			digest := sha256.New()
+			if _, err := io.Copy(digest, part); err != nil {
+				return nil, false
+			}
+			parts = append(parts, [3]string{part.FormName(), "file", hex.EncodeToString(digest.Sum(nil))})
+			continue
+		}
+		value, err := io.ReadAll(part)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, [3]string{part.FormName(), "field", string(value)})
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		for k := range parts[i] {
+			if parts[i][k] != parts[j][k] {
+				return parts[i][k] < parts[j][k]
+			}
+		}
+		return false
+	})
+	return parts, true
+}
@@ -13,6 +13,25 @@ import (
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
+
+		// Browsers' EventSource can't set headers, so event streams may pass a
+		// single-use ticket from POST /events/ticket instead of the token
+		if authHeader == "" && c.Query("ticket") != "" && strings.HasSuffix(c.FullPath(), "/events") {
+			userInfo, isValid := auth.RedeemStreamTicket(c.Query("ticket"))
+			if !isValid {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ticket"})
+				c.Abort()
+				return
+			}
+
+			c.Set("user_id", userInfo.UserID)
+			c.Set("user_role", userInfo.Role)
+			c.Set("username", userInfo.Username)
+
+			c.Next()
+			return
+		}
+
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
 			c.Abort()
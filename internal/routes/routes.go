@@ -19,18 +19,38 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 	adminUserHandler := admin.NewUserHandler(db)
 	adminCategoryHandler := admin.NewCategoryHandler(db)
 	adminProductHandler := admin.NewProductHandler(db)
+	adminTopUpHandler := admin.NewTopUpHandler(db)
+	adminSettlementHandler := admin.NewSettlementHandler(db)
+	adminReviewHandler := admin.NewReviewHandler(db)
+	adminOrderHandler := admin.NewOrderHandler(db)
+	adminReconcileHandler := admin.NewReconcileHandler(db)
+	adminAdjustmentHandler := admin.NewAdjustmentHandler(db)
+	adminDepartureHandler := admin.NewDepartureHandler(db)
 	
 	// Student handlers
 	siswaUserHandler := siswa.NewUserHandler(db)
 	siswaOrderHandler := siswa.NewOrderHandler(db)
 	siswaMenuHandler := siswa.NewMenuHandler(db)
 	siswaCartHandler := siswa.NewCartHandler(db)
+	siswaTopUpHandler := siswa.NewTopUpHandler(db)
+	siswaNotificationHandler := siswa.NewNotificationHandler(db)
+	siswaEventHandler := siswa.NewEventHandler(db)
+	siswaPickupSlotHandler := siswa.NewPickupSlotHandler(db)
+	siswaFavoriteHandler := siswa.NewFavoriteHandler(db)
+	siswaUsualOrderHandler := siswa.NewUsualOrderHandler(db)
+	siswaReviewHandler := siswa.NewReviewHandler(db)
 	
 	// Stand handlers
 	standProductHandler := stand.NewProductHandler(db)
+	standOptionGroupHandler := stand.NewOptionGroupHandler(db)
 	standOrderHandler := stand.NewOrderHandler(db)
 	standSettingsHandler := stand.NewSettingsHandler(db)
 	standCategoryHandler := stand.NewCategoryHandler(db)
+	standSettlementHandler := stand.NewSettlementHandler(db)
+	standEventHandler := stand.NewEventHandler(db)
+	standPickupSlotHandler := stand.NewPickupSlotHandler(db)
+	standReviewHandler := stand.NewReviewHandler(db)
+	standKitchenHandler := stand.NewKitchenHandler(db)
 	
 	// API v1 group
 	v1 := router.Group("/api/v1")
@@ -60,11 +80,53 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 			siswaGroup.GET("/balance", siswaUserHandler.GetBalance)
 			siswaGroup.GET("/orders", siswaOrderHandler.GetOrders)
 			siswaGroup.GET("/orders/monthly", siswaOrderHandler.GetOrdersByMonth)
+			siswaGroup.GET("/orders/report", siswaOrderHandler.GetSpendingReport)
 			siswaGroup.GET("/orders/:id/receipt", siswaOrderHandler.GetOrderReceipt)
-			siswaGroup.POST("/orders", siswaOrderHandler.CreateOrder)
+			siswaGroup.GET("/orders/:id/timeline", siswaOrderHandler.GetOrderTimeline)
+			siswaGroup.GET("/events", siswaEventHandler.StreamEvents)
+			siswaGroup.POST("/events/ticket", siswaEventHandler.IssueTicket)
+			siswaGroup.POST("/orders", IdempotencyMiddleware(db), siswaOrderHandler.CreateOrder)
 			siswaGroup.DELETE("/orders/:id", siswaOrderHandler.DeleteOrder)
+			siswaGroup.POST("/orders/:id/reorder", siswaCartHandler.Reorder)
+			siswaGroup.GET("/checkouts/:id", siswaOrderHandler.GetCheckout)
+
+			// Ratings and reviews
+			siswaGroup.POST("/orders/:id/reviews", siswaReviewHandler.CreateReviews)
+			siswaGroup.GET("/orders/:id/reviews", siswaReviewHandler.GetOrderReviews)
+			siswaGroup.GET("/products/:id/reviews", siswaReviewHandler.GetProductReviews)
+			siswaGroup.GET("/stands", siswaReviewHandler.GetStands)
+
+			// Favourites and usual orders
+			siswaGroup.GET("/favorites", siswaFavoriteHandler.GetFavorites)
+			siswaGroup.POST("/favorites", siswaFavoriteHandler.AddFavorite)
+			siswaGroup.DELETE("/favorites/:product_id", siswaFavoriteHandler.RemoveFavorite)
+			siswaGroup.GET("/usual-orders", siswaUsualOrderHandler.GetUsualOrders)
+			siswaGroup.POST("/usual-orders", siswaUsualOrderHandler.CreateUsualOrder)
+			siswaGroup.DELETE("/usual-orders/:id", siswaUsualOrderHandler.DeleteUsualOrder)
+			siswaGroup.POST("/usual-orders/:id/checkout", IdempotencyMiddleware(db), siswaCartHandler.CheckoutUsualOrder)
+
 			siswaGroup.GET("/transactions", siswaUserHandler.GetTransactions)
+			siswaGroup.GET("/transactions/statement", siswaUserHandler.GetStatement)
 			siswaGroup.GET("/products", siswaMenuHandler.GetProducts)
+			siswaGroup.GET("/stands/:stand_id/pickup-slots", siswaPickupSlotHandler.GetPickupSlots)
+
+			// Top-up requests
+			topUpRequests := siswaGroup.Group("/topup-requests")
+			{
+				topUpRequests.GET("", siswaTopUpHandler.GetTopUpRequests)
+				topUpRequests.GET("/:id", siswaTopUpHandler.GetTopUpRequest)
+				topUpRequests.POST("", IdempotencyMiddleware(db), siswaTopUpHandler.CreateTopUpRequest)
+			}
+
+			// Notifications
+			notifications := siswaGroup.Group("/notifications")
+			{
+				notifications.GET("", siswaNotificationHandler.GetNotifications)
+				notifications.PUT("/read-all", siswaNotificationHandler.MarkAllAsRead)
+				notifications.PUT("/:id/read", siswaNotificationHandler.MarkAsRead)
+			}
+			siswaGroup.GET("/notification-settings", siswaNotificationHandler.GetSettings)
+			siswaGroup.PUT("/notification-settings", siswaNotificationHandler.UpdateSettings)
 			
 			// Cart management
 			cart := siswaGroup.Group("/cart")
@@ -74,7 +136,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 				cart.PUT("/items/:id", siswaCartHandler.UpdateCartItem)
 				cart.DELETE("/items/:id", siswaCartHandler.RemoveFromCart)
 				cart.DELETE("", siswaCartHandler.ClearCart)
-				cart.POST("/checkout", siswaCartHandler.Checkout)
+				cart.POST("/checkout", IdempotencyMiddleware(db), siswaCartHandler.Checkout)
 				cart.GET("/qris/:stand_id", siswaCartHandler.GetQRISCode)
 				cart.GET("/orders/:order_id/qris", siswaCartHandler.GetQRISByOrder)
 				cart.POST("/orders/:order_id/payment-proof", siswaCartHandler.UploadPaymentProof)
@@ -85,6 +147,10 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 		standGroup := v1.Group("/stand")
 		standGroup.Use(AuthMiddleware(), StandMiddleware())
 		{
+			// Real-time order updates
+			standGroup.GET("/events", standEventHandler.StreamEvents)
+			standGroup.POST("/events/ticket", standEventHandler.IssueTicket)
+
 			// Product management
 			products := standGroup.Group("/products")
 			{
@@ -94,21 +160,49 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 				products.PUT("/:id", standProductHandler.UpdateProduct)
 				products.DELETE("/:id", standProductHandler.DeleteProduct)
 				products.PUT("/:id/status", standProductHandler.UpdateProductStatus)
+				products.GET("/:id/option-groups", standOptionGroupHandler.GetOptionGroups)
+				products.POST("/:id/option-groups", standOptionGroupHandler.CreateOptionGroup)
+				products.PUT("/:id/option-groups/:group_id", standOptionGroupHandler.UpdateOptionGroup)
+				products.DELETE("/:id/option-groups/:group_id", standOptionGroupHandler.DeleteOptionGroup)
 			}
 			
 			// Order management
 			orders := standGroup.Group("/orders")
 			{
 				orders.GET("", standOrderHandler.GetOrders)
+				orders.GET("/search", standOrderHandler.SearchOrders)
 				orders.GET("/pending", standOrderHandler.GetPendingOrders)
+				orders.POST("/pickup", standOrderHandler.ConfirmPickup)
+				orders.GET("/scheduled", standPickupSlotHandler.GetScheduledOrders)
 				orders.GET("/:id", standOrderHandler.GetOrder)
-				orders.POST("", standOrderHandler.CreateOrder)
+				orders.GET("/:id/timeline", standOrderHandler.GetOrderTimeline)
+				orders.GET("/:id/receipt", standOrderHandler.GetOrderReceipt)
+				orders.POST("", IdempotencyMiddleware(db), standOrderHandler.CreateOrder)
 				orders.PUT("/:id/status", standOrderHandler.UpdateOrderStatus)
+				orders.PUT("/:id/items/:item_id", standOrderHandler.UpdateOrderItem)
+				orders.POST("/:id/refund-paid", standOrderHandler.MarkRefundPaid)
 				orders.DELETE("/:id", standOrderHandler.DeleteOrder)
 				orders.GET("/monthly", standOrderHandler.GetOrdersByMonth)
 				orders.GET("/revenue/monthly", standOrderHandler.GetMonthlyRevenueRecap)
+				orders.GET("/report", standOrderHandler.GetOrderReport)
 			}
 			
+			// Kitchen display
+			kitchen := standGroup.Group("/kitchen")
+			{
+				kitchen.GET("", standKitchenHandler.GetKitchen)
+				kitchen.POST("/bump", standKitchenHandler.BumpOrders)
+			}
+			
+			// Pre-order pickup slots
+			pickupSlots := standGroup.Group("/pickup-slots")
+			{
+				pickupSlots.GET("", standPickupSlotHandler.GetPickupSlots)
+				pickupSlots.POST("", standPickupSlotHandler.CreatePickupSlot)
+				pickupSlots.PUT("/:id", standPickupSlotHandler.UpdatePickupSlot)
+				pickupSlots.DELETE("/:id", standPickupSlotHandler.DeletePickupSlot)
+			}
+
 			// Category management
 			categories := standGroup.Group("/categories")
 			{
@@ -123,6 +217,18 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 				settings.PUT("/qris", standSettingsHandler.UpdateQRIS)
 				settings.PUT("/store-name", standSettingsHandler.UpdateStoreName)
 			}
+
+			// Settlement statements
+			settlements := standGroup.Group("/settlements")
+			{
+				settlements.GET("", standSettlementHandler.GetSettlements)
+				settlements.GET("/current", standSettlementHandler.GetCurrentSettlement)
+				settlements.GET("/:id", standSettlementHandler.GetSettlement)
+			}
+
+			// Reviews
+			standGroup.GET("/reviews", standReviewHandler.GetReviews)
+			standGroup.PUT("/reviews/:id/reply", standReviewHandler.ReplyToReview)
 		}
 		
 		// Admin routes (protected + admin role)
@@ -137,7 +243,41 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 				users.POST("", adminUserHandler.CreateUser)
 				users.PUT("/:id", adminUserHandler.UpdateUser)
 				users.DELETE("/:id", adminUserHandler.DeleteUser)
-				users.POST("/:id/topup", adminUserHandler.TopUpBalance)
+				users.POST("/:id/topup", IdempotencyMiddleware(db), adminUserHandler.TopUpBalance)
+				users.POST("/:id/adjustments", IdempotencyMiddleware(db), adminAdjustmentHandler.CreateAdjustment)
+			}
+
+			// Balance adjustments
+			adjustments := adminGroup.Group("/adjustments")
+			{
+				adjustments.GET("", adminAdjustmentHandler.GetAdjustments)
+				adjustments.POST("/:id/approve", adminAdjustmentHandler.ApproveAdjustment)
+				adjustments.POST("/:id/reject", adminAdjustmentHandler.RejectAdjustment)
+			}
+
+			// Graduation and departure cash-out
+			departures := adminGroup.Group("/departures")
+			{
+				departures.GET("", adminDepartureHandler.GetDepartures)
+				departures.POST("", adminDepartureHandler.FlagDepartures)
+				departures.POST("/:id/complete", IdempotencyMiddleware(db), adminDepartureHandler.CompleteDeparture)
+				departures.DELETE("/:id", adminDepartureHandler.CancelDeparture)
+			}
+
+			// Wallet reconciliation
+			wallets := adminGroup.Group("/wallets")
+			{
+				wallets.GET("/reconcile", adminReconcileHandler.GetReconciliation)
+				wallets.POST("/reconcile/repair", adminReconcileHandler.RepairWallets)
+			}
+
+			// Top-up request review queue
+			topUpRequests := adminGroup.Group("/topup-requests")
+			{
+				topUpRequests.GET("", adminTopUpHandler.GetTopUpRequests)
+				topUpRequests.GET("/:id", adminTopUpHandler.GetTopUpRequest)
+				topUpRequests.POST("/:id/approve", adminTopUpHandler.ApproveTopUpRequest)
+				topUpRequests.POST("/:id/reject", adminTopUpHandler.RejectTopUpRequest)
 			}
 			
 			// Category management
@@ -166,6 +306,35 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB) {
 				globalSettings.GET("", adminCategoryHandler.GetGlobalSettings)
 				globalSettings.PUT("/:key", adminCategoryHandler.UpdateGlobalSetting)
 			}
+
+			// Order search across stands
+			adminGroup.GET("/orders", adminOrderHandler.GetOrders)
+
+			// Review moderation
+			reviews := adminGroup.Group("/reviews")
+			{
+				reviews.GET("", adminReviewHandler.GetReviews)
+				reviews.POST("/:id/hide", adminReviewHandler.HideReview)
+				reviews.POST("/:id/unhide", adminReviewHandler.UnhideReview)
+				reviews.DELETE("/:id/reply", adminReviewHandler.RemoveReply)
+			}
+
+			// Stand settlements
+			settlements := adminGroup.Group("/settlements")
+			{
+				settlements.GET("", adminSettlementHandler.GetSettlements)
+				settlements.GET("/preview", adminSettlementHandler.PreviewSettlement)
+				settlements.GET("/:id", adminSettlementHandler.GetSettlement)
+				settlements.POST("", adminSettlementHandler.CloseSettlement)
+			}
+
+			// Payout batches
+			payoutBatches := adminGroup.Group("/payout-batches")
+			{
+				payoutBatches.GET("", adminSettlementHandler.GetPayoutBatches)
+				payoutBatches.POST("", adminSettlementHandler.CreatePayoutBatch)
+				payoutBatches.PUT("/:id/paid", adminSettlementHandler.MarkPayoutBatchPaid)
+			}
 		}
 	}
 }